@@ -0,0 +1,58 @@
+package grpcget_bytesvalue
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+//
+// Parsing of bytes values, shared by grpcget and the DMH plugins
+//
+
+// Encoding prefixes of bytes values
+var Prefixes = []string{"base64:", "hex:", "file:", "text:"}
+
+// Parses a bytes value. The value can have an encoding prefix: "base64:", "hex:", "file:" to read the contents
+// of a file, or "text:" for text that could be mistaken for a prefix. Values without a prefix are used as UTF-8 text.
+func Parse(value string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(value, "base64:"):
+		b, err := DecodeBase64(strings.TrimPrefix(value, "base64:"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid base64 value: %v", err)
+		}
+		return b, nil
+	case strings.HasPrefix(value, "hex:"):
+		b, err := hex.DecodeString(strings.TrimPrefix(value, "hex:"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid hex value: %v", err)
+		}
+		return b, nil
+	case strings.HasPrefix(value, "file:"):
+		return ioutil.ReadFile(strings.TrimPrefix(value, "file:"))
+	case strings.HasPrefix(value, "text:"):
+		return []byte(strings.TrimPrefix(value, "text:")), nil
+	}
+	return []byte(value), nil
+}
+
+var base64Codecs = []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding}
+
+// Decodes a base64 value. We are lenient and accept any of the flavors of base64 encoding.
+func DecodeBase64(val string) ([]byte, error) {
+	var firstErr error
+	for _, d := range base64Codecs {
+		b, err := d.DecodeString(val)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		return b, nil
+	}
+	return nil, firstErr
+}
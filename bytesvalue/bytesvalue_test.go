@@ -0,0 +1,56 @@
+package grpcget_bytesvalue
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.bin")
+	err := ioutil.WriteFile(file, []byte("file\x00data"), 0600)
+	if err != nil {
+		t.Fatalf("Error writing file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "no prefix", value: "hello world", want: []byte("hello world")},
+		{name: "no prefix empty", value: "", want: []byte("")},
+		{name: "unknown prefix", value: "other:value", want: []byte("other:value")},
+		{name: "base64", value: "base64:aGkA", want: []byte("hi\x00")},
+		{name: "base64 url", value: "base64:-_8=", want: []byte{0xfb, 0xff}},
+		{name: "base64 raw", value: "base64:aGk", want: []byte("hi")},
+		{name: "base64 invalid", value: "base64:a$b", wantErr: true},
+		{name: "hex", value: "hex:0a1b2c", want: []byte{0x0a, 0x1b, 0x2c}},
+		{name: "hex uppercase", value: "hex:0A1B2C", want: []byte{0x0a, 0x1b, 0x2c}},
+		{name: "hex invalid", value: "hex:0g", wantErr: true},
+		{name: "hex odd length", value: "hex:abc", wantErr: true},
+		{name: "file", value: "file:" + file, want: []byte("file\x00data")},
+		{name: "file not found", value: "file:" + file + ".missing", wantErr: true},
+		{name: "text", value: "text:hex:0a", want: []byte("hex:0a")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error parsing value: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Value is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/RangelReale/grpcget"
@@ -38,6 +40,21 @@ func NewCmd() *Cmd {
 		cli.StringFlag{Name: "connect-timeout", Usage: "The maximum time, in seconds, to wait for connection to be established. Defaults to 10 seconds."},
 		cli.StringFlag{Name: "servername", Usage: "Override servername when validating TLS certificate."},
 		cli.Float64Flag{Name: "max-time", Usage: "The maximum total time the operation can take. This is useful for preventing batch jobs that use grpcurl from hanging due to slow or bad network links or due to incorrect stream method usage."},
+		cli.StringSliceFlag{Name: "proto", Usage: "Proto source file to use for descriptors instead of the server reflection. Can be repeated."},
+		cli.StringSliceFlag{Name: "import-path", Usage: "Path used to find the -proto files and their imports. Can be repeated."},
+		cli.StringSliceFlag{Name: "protoset", Usage: "Protoset file (a binary encoded FileDescriptorSet) to use for descriptors instead of the server reflection. Can be repeated."},
+		cli.BoolFlag{Name: "reflection", Usage: "Also use the server reflection when -proto or -protoset are set, for symbols not found in the files."},
+		cli.BoolFlag{Name: "cache", Usage: "Cache the descriptors received from the server reflection on disk."},
+		cli.StringFlag{Name: "cache-dir", Usage: "Directory of the descriptor cache. Defaults to a grpcget directory inside the user cache directory."},
+		cli.DurationFlag{Name: "cache-ttl", Value: 24 * time.Hour, Usage: "Time after which the descriptor cache is refreshed, 0 never expires."},
+		cli.BoolFlag{Name: "refresh-cache", Usage: "Refresh the descriptor cache even if it is still valid. Implies -cache."},
+		cli.StringFlag{Name: "reflection-version", Value: "v1", Usage: "Version of the reflection service to try first, v1 or v1alpha. The other version is used if the server doesn't support it."},
+		cli.StringFlag{Name: "format", Value: "default", Usage: "Output format: default, json, text (protobuf text format), yaml, xml, table or csv (table and csv only for invoke)."},
+		cli.StringFlag{Name: "bytes-encoding", Value: "base64", Usage: "Encoding of bytes fields in the default format and -select output: base64, hex, or text for printable UTF-8 as is and other values as base64 with the base64: prefix."},
+		cli.StringFlag{Name: "json-indent", Value: "  ", Usage: "Indentation of the json format, blank outputs each message in a single line."},
+		cli.BoolFlag{Name: "json-orig-name", Usage: "Use the original proto field names in the json format instead of the JSON names."},
+		cli.BoolFlag{Name: "json-emit-defaults", Usage: "Output fields with default values in the json format."},
+		cli.BoolFlag{Name: "json-enums-as-ints", Usage: "Output enum values as numbers in the json format."},
 		cli.Float64Flag{Name: "keepalive-time", Usage: "If present, the maximum idle time in seconds, after which a keepalive probe is sent. If the connection remains idle and no keepalive response is received for this same period then the connection is closed and the operation fails."},
 	}
 
@@ -53,14 +70,48 @@ func NewCmd() *Cmd {
 			Name: "describe",
 			Flags: []cli.Flag{
 				cli.StringSliceFlag{Name: "md", Usage: "Metadata to send in name=value format."},
+				cli.BoolFlag{Name: "proto", Usage: "Output the description as .proto source."},
 			},
 			Action: ret.CmdDescribe,
 		},
+		{
+			Name:      "export",
+			Usage:     "Export the descriptors of all services to a protoset file, or as .proto files to a directory",
+			ArgsUsage: "hostname:port filename|directory",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{Name: "md", Usage: "Metadata to send in name=value format."},
+				cli.BoolFlag{Name: "proto", Usage: "Write reconstructed .proto source files into the directory."},
+			},
+			Action: ret.CmdExport,
+		},
+		{
+			Name:  "cache",
+			Usage: "Manage the descriptor cache",
+			Subcommands: []cli.Command{
+				{
+					Name:   "clear",
+					Usage:  "Clear the cached descriptors of a hostname:port, or of all servers if not set",
+					Action: ret.CmdCacheClear,
+				},
+			},
+		},
 		{
 			Name: "invoke",
 			Flags: []cli.Flag{
 				cli.StringSliceFlag{Name: "md", Usage: "Metadata to send in name=value format."},
 				cli.BoolFlag{Name: "describe", Usage: "Describe the method instead of invoking the function"},
+				cli.BoolFlag{Name: "stdin", Usage: "Read the request messages from stdin, one per line, as JSON or name=value params. Bidirectional streaming methods send each line as it is read."},
+				cli.StringFlag{Name: "d", Usage: "Request message as JSON, text format or YAML, from a file with @file or from stdin with @-. Params set after it override its fields."},
+				cli.StringFlag{Name: "input-format", Usage: "Format of the -d request message (json, text, yaml). Detected from the file extension by default, or json."},
+				cli.StringFlag{Name: "request-separator", Value: "+", Usage: "Parameter that separates the request messages of client streaming methods."},
+				cli.BoolFlag{Name: "include-metadata, v", Usage: "Output the request metadata, response headers, trailers and status, similar to curl -i."},
+				cli.StringFlag{Name: "table", Usage: "Output the repeated message field as a table, blank to detect. Implies -format table if the format is not csv."},
+				cli.StringFlag{Name: "columns", Usage: "Comma separated columns of the table output, with dotted names for nested fields."},
+				cli.StringFlag{Name: "sort", Usage: "Comma separated columns to sort the table output, prefix with - to sort descending."},
+				cli.StringFlag{Name: "select", Usage: "Output the values selected by a path from each response message, like 'items[*].id' or 'items[?state==\"ACTIVE\"].name'."},
+				cli.BoolFlag{Name: "select-json", Usage: "Output the selected values as JSON instead of raw values. Implied by -format json."},
+				cli.StringFlag{Name: "template", Usage: "Output each response message with a Go text/template, like '{{.message}} ({{len .items}})'."},
+				cli.StringFlag{Name: "template-file", Usage: "Output each response message with a Go text/template read from the file."},
 			},
 			Action: ret.CmdInvoke,
 		},
@@ -87,6 +138,12 @@ func (c *Cmd) InitialCheck(ctx *cli.Context) error {
 	if (ctx.GlobalString("key") == "") != (ctx.GlobalString("cert") == "") {
 		return errors.New("The -cert and -key arguments must be used together and both be present.")
 	}
+	if len(ctx.GlobalStringSlice("import-path")) > 0 && len(ctx.GlobalStringSlice("proto")) == 0 {
+		return errors.New("The -import-path argument requires the -proto argument.")
+	}
+	if ctx.GlobalIsSet("reflection") && len(ctx.GlobalStringSlice("proto")) == 0 && len(ctx.GlobalStringSlice("protoset")) == 0 {
+		return errors.New("The -reflection argument requires the -proto or -protoset arguments.")
+	}
 	return nil
 }
 
@@ -157,9 +214,192 @@ func (c *Cmd) getGrpcGet(ctx *cli.Context, target string) (*grpcget.GrpcGet, con
 	// set grpcget options
 	gg.SetOpts(grpcget.WithDefaultConnection(dialctx, c.Override.OverrideTargetAddress(target), gdopts...))
 
+	// output format
+	if err := c.setOutputFormat(ctx, gg); err != nil {
+		return nil, nil, err
+	}
+
+	// descriptor sources
+	reflSource := grpcget.NewReflectionDescriptorSourceSupplier()
+	switch ctx.GlobalString("reflection-version") {
+	case "v1":
+		reflSource.VersionOrder = grpcget.ReflectionV1First
+	case "v1alpha":
+		reflSource.VersionOrder = grpcget.ReflectionV1AlphaFirst
+	default:
+		return nil, nil, fmt.Errorf("Invalid reflection version %q, must be v1 or v1alpha", ctx.GlobalString("reflection-version"))
+	}
+
+	var reflSupplier grpcget.DescriptorSourceSupplier = reflSource
+	if ctx.GlobalIsSet("cache") || ctx.GlobalIsSet("refresh-cache") {
+		cacheDir, err := c.cacheDir(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		cacheSource := grpcget.NewCacheDescriptorSourceSupplier(reflSource, cacheDir, ctx.GlobalDuration("cache-ttl"))
+		cacheSource.Refresh = ctx.GlobalIsSet("refresh-cache")
+		reflSupplier = cacheSource
+	}
+
+	var dsources []grpcget.DescriptorSourceSupplier
+	if len(ctx.GlobalStringSlice("proto")) > 0 {
+		dsources = append(dsources, grpcget.NewProtoFileDescriptorSourceSupplier(ctx.GlobalStringSlice("import-path"), ctx.GlobalStringSlice("proto")...))
+	}
+	if len(ctx.GlobalStringSlice("protoset")) > 0 {
+		dsources = append(dsources, grpcget.NewProtosetDescriptorSourceSupplier(ctx.GlobalStringSlice("protoset")...))
+	}
+	if len(dsources) > 0 {
+		if ctx.GlobalIsSet("reflection") {
+			dsources = append(dsources, reflSupplier)
+		}
+		if len(dsources) == 1 {
+			gg.SetOpts(grpcget.WithDescriptorSource(dsources[0]))
+		} else {
+			gg.SetOpts(grpcget.WithDescriptorSource(grpcget.NewCombinedDescriptorSourceSupplier(dsources...)))
+		}
+	} else if ctx.GlobalIsSet("reflection-version") || reflSupplier != reflSource {
+		gg.SetOpts(grpcget.WithDescriptorSource(reflSupplier))
+	}
+
 	return gg, callctx, nil
 }
 
+// Sets the outputs for the -format flag. The default format keeps the configured outputs, and an error output
+// configured on Cmd.GrpcGet is always kept.
+func (c *Cmd) setOutputFormat(ctx *cli.Context, gg *grpcget.GrpcGet) error {
+	// error details are output to stderr
+	var errorOutput grpcget.InvokeErrorOutput = grpcget.NewDefaultInvokeErrorOutput(os.Stderr)
+
+	switch ctx.GlobalString("format") {
+	case "default":
+		if ctx.GlobalString("bytes-encoding") != "base64" {
+			encoding, err := c.bytesEncoding(ctx)
+			if err != nil {
+				return err
+			}
+			output := grpcget.NewDefaultInvokeOutput(os.Stdout)
+			output.BytesEncoding = encoding
+			gg.SetOpts(grpcget.WithOutputInvoke(output))
+		}
+	case "text":
+		gg.SetOpts(grpcget.WithOutputInvoke(grpcget.NewTextInvokeOutput(os.Stdout)))
+	case "yaml":
+		gg.SetOpts(grpcget.WithOutputInvoke(grpcget.NewYAMLInvokeOutput(os.Stdout)))
+	case "table", "csv":
+		// set by the invoke command
+	case "xml":
+		gg.SetOpts(grpcget.WithOutputServiceList(grpcget.NewXMLServiceListOutput(os.Stdout)),
+			grpcget.WithOutputService(grpcget.NewXMLServiceOutput(os.Stdout)),
+			grpcget.WithOutputDescribe(grpcget.NewXMLDescribeOutput(os.Stdout)),
+			grpcget.WithOutputInvoke(grpcget.NewXMLInvokeOutput(os.Stdout)))
+	case "json":
+		output := grpcget.NewJSONInvokeOutput(os.Stdout)
+		output.Indent = ctx.GlobalString("json-indent")
+		output.OrigName = ctx.GlobalIsSet("json-orig-name")
+		output.EmitDefaults = ctx.GlobalIsSet("json-emit-defaults")
+		output.EnumsAsInts = ctx.GlobalIsSet("json-enums-as-ints")
+
+		listOutput := grpcget.NewJSONServiceListOutput(os.Stdout)
+		listOutput.Indent = output.Indent
+		serviceOutput := grpcget.NewJSONServiceOutput(os.Stdout)
+		serviceOutput.Indent = output.Indent
+		describeOutput := grpcget.NewJSONDescribeOutput(os.Stdout)
+		describeOutput.Indent = output.Indent
+		jsonErrorOutput := grpcget.NewJSONInvokeErrorOutput(os.Stderr)
+		jsonErrorOutput.Indent = output.Indent
+		jsonErrorOutput.OrigName = output.OrigName
+		errorOutput = jsonErrorOutput
+
+		gg.SetOpts(grpcget.WithOutputServiceList(listOutput),
+			grpcget.WithOutputService(serviceOutput),
+			grpcget.WithOutputDescribe(describeOutput),
+			grpcget.WithOutputInvoke(output))
+	default:
+		return fmt.Errorf("Invalid output format %q", ctx.GlobalString("format"))
+	}
+
+	gg.SetOpts(grpcget.WithOutputInvokeErrorIfUnset(errorOutput))
+	return nil
+}
+
+// Returns the bytes encoding from the -bytes-encoding flag
+func (c *Cmd) bytesEncoding(ctx *cli.Context) (grpcget.BytesEncoding, error) {
+	switch ctx.GlobalString("bytes-encoding") {
+	case "base64":
+		return grpcget.BytesBase64, nil
+	case "hex":
+		return grpcget.BytesHex, nil
+	case "text":
+		return grpcget.BytesText, nil
+	}
+	return grpcget.BytesBase64, fmt.Errorf("Invalid bytes encoding %q, must be base64, hex or text", ctx.GlobalString("bytes-encoding"))
+}
+
+// Checks that only one of the table, template and select outputs was requested
+func (c *Cmd) checkInvokeOutput(ctx *cli.Context) error {
+	var outputs []string
+	if c.isTableOutput(ctx) {
+		outputs = append(outputs, "table")
+	}
+	if ctx.IsSet("template") || ctx.IsSet("template-file") {
+		outputs = append(outputs, "-template")
+	}
+	if ctx.IsSet("select") {
+		outputs = append(outputs, "-select")
+	}
+	if len(outputs) > 1 {
+		return fmt.Errorf("The %s outputs can't be used together", strings.Join(outputs, " and "))
+	}
+	return nil
+}
+
+// Returns whether the table output was requested, with -format table or csv or the table flags
+func (c *Cmd) isTableOutput(ctx *cli.Context) bool {
+	format := ctx.GlobalString("format")
+	return format == "table" || format == "csv" || ctx.IsSet("table") || ctx.IsSet("columns") || ctx.IsSet("sort")
+}
+
+// Returns the table output from the table flags
+func (c *Cmd) tableOutput(ctx *cli.Context) *grpcget.TableInvokeOutput {
+	output := grpcget.NewTableInvokeOutput(os.Stdout)
+	output.Field = ctx.String("table")
+	output.CSV = ctx.GlobalString("format") == "csv"
+	if ctx.String("columns") != "" {
+		output.Columns = strings.Split(ctx.String("columns"), ",")
+	}
+	if ctx.String("sort") != "" {
+		output.SortBy = strings.Split(ctx.String("sort"), ",")
+	}
+	return output
+}
+
+// Returns the template output from the -template or -template-file flags
+func (c *Cmd) templateOutput(ctx *cli.Context) (*grpcget.TemplateInvokeOutput, error) {
+	if ctx.IsSet("template") && ctx.IsSet("template-file") {
+		return nil, errors.New("The -template and -template-file arguments are mutually exclusive.")
+	}
+
+	text := ctx.String("template")
+	if ctx.IsSet("template-file") {
+		b, err := ioutil.ReadFile(ctx.String("template-file"))
+		if err != nil {
+			return nil, fmt.Errorf("Error reading template file: %v", err)
+		}
+		// a new line is already output after each message
+		text = strings.TrimSuffix(string(b), "\n")
+	}
+
+	return grpcget.NewTemplateInvokeOutput(os.Stdout, text)
+}
+
+// Returns the descriptor cache directory
+func (c *Cmd) cacheDir(ctx *cli.Context) (string, error) {
+	if ctx.GlobalString("cache-dir") != "" {
+		return ctx.GlobalString("cache-dir"), nil
+	}
+	return grpcget.DefaultDescriptorCacheDir()
+}
+
 // LIST
 func (c *Cmd) CmdList(ctx *cli.Context) error {
 	if err := c.InitialCheck(ctx); err != nil {
@@ -214,9 +454,76 @@ func (c *Cmd) CmdDescribe(ctx *cli.Context) error {
 		return err
 	}
 
+	if ctx.IsSet("proto") {
+		gget.SetOpts(grpcget.WithOutputDescribe(grpcget.NewProtoDescribeOutput(os.Stdout)))
+	}
+
 	return gget.Describe(callctx, c.Override.OverrideDescribeSymbolName(ctx.Args().Get(1)))
 }
 
+// EXPORT
+func (c *Cmd) CmdExport(ctx *cli.Context) error {
+	if err := c.InitialCheck(ctx); err != nil {
+		return err
+	}
+
+	if ctx.NArg() < 1 {
+		return errors.New("First argument must be hostname:port")
+	}
+
+	if ctx.NArg() < 2 {
+		if ctx.IsSet("proto") {
+			return errors.New("Second argument must be the output directory")
+		}
+		return errors.New("Second argument must be the output file name, or - for stdout")
+	}
+
+	gget, callctx, err := c.getGrpcGet(ctx, ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet("proto") {
+		return gget.ExportProtoFiles(callctx, ctx.Args().Get(1))
+	}
+
+	filename := ctx.Args().Get(1)
+	if filename == "-" {
+		return gget.ExportDescriptors(callctx, os.Stdout)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	err = gget.ExportDescriptors(callctx, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return err
+	}
+
+	return nil
+}
+
+// CACHE CLEAR
+func (c *Cmd) CmdCacheClear(ctx *cli.Context) error {
+	cacheDir, err := c.cacheDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := ""
+	if ctx.NArg() > 0 {
+		target = c.Override.OverrideTargetAddress(ctx.Args().Get(0))
+	}
+
+	return grpcget.ClearDescriptorCache(cacheDir, target)
+}
+
 // INVOKE
 func (c *Cmd) CmdInvoke(ctx *cli.Context) error {
 	if err := c.InitialCheck(ctx); err != nil {
@@ -231,6 +538,10 @@ func (c *Cmd) CmdInvoke(ctx *cli.Context) error {
 		return errors.New("Second argument must be a method name")
 	}
 
+	if err := c.checkInvokeOutput(ctx); err != nil {
+		return err
+	}
+
 	gget, callctx, err := c.getGrpcGet(ctx, ctx.Args().Get(0))
 	if err != nil {
 		return err
@@ -243,12 +554,86 @@ func (c *Cmd) CmdInvoke(ctx *cli.Context) error {
 		return gget.Describe(callctx, method)
 	}
 
+	if ctx.IsSet("include-metadata") {
+		gget.SetOpts(grpcget.WithOutputInvokeMetadata(grpcget.NewDefaultInvokeMetadataOutput(os.Stdout)))
+	}
+
+	if c.isTableOutput(ctx) {
+		gget.SetOpts(grpcget.WithOutputInvoke(c.tableOutput(ctx)))
+	}
+
+	if ctx.IsSet("template") || ctx.IsSet("template-file") {
+		output, err := c.templateOutput(ctx)
+		if err != nil {
+			return err
+		}
+		gget.SetOpts(grpcget.WithOutputInvoke(output))
+	}
+
+	if ctx.IsSet("select") {
+		path, err := grpcget.ParseSelectPath(ctx.String("select"))
+		if err != nil {
+			return err
+		}
+		encoding, err := c.bytesEncoding(ctx)
+		if err != nil {
+			return err
+		}
+		output := grpcget.NewSelectInvokeOutput(os.Stdout, path)
+		output.JSON = ctx.IsSet("select-json") || ctx.GlobalString("format") == "json"
+		output.BytesEncoding = encoding
+		gget.SetOpts(grpcget.WithOutputInvoke(output))
+	}
+
 	var params []string
 	for pi := 2; pi < ctx.NArg(); pi++ {
 		params = append(params, ctx.Args().Get(pi))
 	}
 
-	return gget.Invoke(callctx, method, grpcget.WithInvokeParams(params...))
+	var iopts []grpcget.InvokeOption
+	if ctx.IsSet("stdin") {
+		if len(params) > 0 || ctx.IsSet("d") {
+			return errors.New("Params are not allowed when reading requests from stdin")
+		}
+		iopts = append(iopts, grpcget.WithInvokeRequestReader(os.Stdin))
+	} else {
+		supplier := grpcget.NewParameterGroupInvokeRequestSupplier(ctx.String("request-separator"), params...)
+		if ctx.IsSet("d") {
+			data, err := ReadDataArgument(ctx.String("d"))
+			if err != nil {
+				return err
+			}
+
+			format := ctx.String("input-format")
+			if format == "" && strings.HasPrefix(ctx.String("d"), "@") {
+				format = grpcget.InputFormatFromFileName(ctx.String("d"))
+			}
+			if format == "" {
+				format = "json"
+			}
+
+			// the data is the base of each request message, params override its fields
+			setter, err := grpcget.NewFormatInvokeParamSetter(format, data)
+			if err != nil {
+				return err
+			}
+			for ri, setters := range supplier.Requests {
+				supplier.Requests[ri] = append([]grpcget.InvokeParamSetter{setter}, setters...)
+			}
+		}
+		iopts = append(iopts, grpcget.WithInvokeRequestSupplier(supplier))
+	}
+
+	// cancel the invoke on Ctrl-C, so streaming methods can be stopped
+	callctx, cancel := ContextCancelOnInterrupt(callctx)
+	defer cancel()
+
+	err = gget.Invoke(callctx, method, iopts...)
+	if err != nil && callctx.Err() == context.Canceled {
+		// interrupted by the user
+		return nil
+	}
+	return err
 }
 
 //
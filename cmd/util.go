@@ -1,11 +1,15 @@
 package grpcget_cmd
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
 
 	"google.golang.org/grpc/credentials"
 )
@@ -50,3 +54,37 @@ func ClientTransportCredentials(insecureSkipVerify bool, cacertFile, clientCertF
 
 	return credentials.NewTLS(&tlsConf), nil
 }
+
+// ContextCancelOnInterrupt returns a context that is cancelled when the process receives an interrupt
+// signal (Ctrl-C). The returned cancel function must be called to release the signal handler.
+func ContextCancelOnInterrupt(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sigch := make(chan os.Signal, 1)
+	signal.Notify(sigch, os.Interrupt)
+
+	go func() {
+		select {
+		case <-sigch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigch)
+		cancel()
+	}
+}
+
+// ReadDataArgument returns the data of a data argument, which is read from a file if it starts with @, or from
+// stdin if it is @-.
+func ReadDataArgument(arg string) ([]byte, error) {
+	switch {
+	case arg == "@-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(arg, "@"):
+		return ioutil.ReadFile(arg[1:])
+	}
+	return []byte(arg), nil
+}
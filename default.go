@@ -2,19 +2,22 @@ package grpcget
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/empty"
-	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 	"google.golang.org/grpc"
-	"time"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 //
@@ -135,6 +138,12 @@ func (d *DefaultDescribeOutput) OutputDescribe(descriptor desc.Descriptor) error
 	var err error
 
 	switch sd := descriptor.(type) {
+	case *desc.FileDescriptor:
+		fmt.Fprintf(d.Out, "File: %s\n", sd.GetName())
+		err = d.DumpFile(1, sd)
+		if err != nil {
+			return err
+		}
 	case *desc.ServiceDescriptor:
 		fmt.Fprintf(d.Out, "Service: %s\n", sd.GetFullyQualifiedName())
 		err = d.DumpService(1, sd)
@@ -172,6 +181,22 @@ func (d *DefaultDescribeOutput) OutputDescribe(descriptor desc.Descriptor) error
 	return nil
 }
 
+func (d *DefaultDescribeOutput) DumpFile(level int, file *desc.FileDescriptor) error {
+	levelStr := strings.Repeat("\t", level)
+
+	for _, svc := range file.GetServices() {
+		fmt.Fprintf(d.Out, "%sService: %s\n", levelStr, svc.GetFullyQualifiedName())
+	}
+	for _, msg := range file.GetMessageTypes() {
+		fmt.Fprintf(d.Out, "%sMessage: %s\n", levelStr, msg.GetFullyQualifiedName())
+	}
+	for _, enum := range file.GetEnumTypes() {
+		fmt.Fprintf(d.Out, "%sEnum: %s\n", levelStr, enum.GetFullyQualifiedName())
+	}
+
+	return nil
+}
+
 func (d *DefaultDescribeOutput) DumpEnum(level int, enum *desc.EnumDescriptor) error {
 	levelStr := strings.Repeat("\t", level)
 
@@ -278,12 +303,18 @@ func (d *DefaultDescribeOutput) DumpField(level int, fld *desc.FieldDescriptor)
 // InvokeOutput
 //
 type DefaultInvokeOutput struct {
-	Out io.Writer
+	Out             io.Writer
+	StreamSeparator string
+	// Encoding of bytes fields
+	BytesEncoding BytesEncoding
+
+	streamCount int
 }
 
 func NewDefaultInvokeOutput(out io.Writer) *DefaultInvokeOutput {
 	return &DefaultInvokeOutput{
-		Out: out,
+		Out:             out,
+		StreamSeparator: "---",
 	}
 }
 
@@ -291,22 +322,80 @@ func (d *DefaultInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Messag
 	return d.DumpMessageCheck(dmh, 0, value)
 }
 
+func (d *DefaultInvokeOutput) OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error {
+	d.streamCount = 0
+	return nil
+}
+
+func (d *DefaultInvokeOutput) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	// separate streamed messages
+	if d.streamCount > 0 {
+		_, err := fmt.Fprintln(d.Out, d.StreamSeparator)
+		if err != nil {
+			return err
+		}
+	}
+	d.streamCount++
+
+	return d.OutputInvoke(dmh, value)
+}
+
+func (d *DefaultInvokeOutput) OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	_, err := fmt.Fprintln(d.Out, d.StreamSeparator)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		// output by the InvokeMetadataOutput
+		return nil
+	}
+
+	if st.Message() != "" {
+		_, err = fmt.Fprintf(d.Out, "status: %s: %s\n", st.Code().String(), st.Message())
+	} else {
+		_, err = fmt.Fprintf(d.Out, "status: %s\n", st.Code().String())
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, tk := range sortedMetadataKeys(trailers) {
+		for _, tv := range trailers[tk] {
+			_, err = fmt.Fprintf(d.Out, "trailer %s: %s\n", tk, tv)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (d *DefaultInvokeOutput) DumpMessageCheck(dmh *DynMsgHelper, level int, msg interface{}) error {
 	levelStr := strings.Repeat("\t", level)
 
+	// well-known types are output inline
+	is_wkt, value, err := d.formatWellKnown(dmh, msg)
+	if err != nil {
+		return err
+	}
+	if is_wkt {
+		_, err = fmt.Fprintf(d.Out, "%s%s\n", levelStr, value)
+		return err
+	}
+
 	switch xvalue := msg.(type) {
 	case *dynamic.Message:
 		return d.DumpMessage(dmh, level, xvalue)
 	case *empty.Empty:
 		_, err := fmt.Fprintf(d.Out, "%s<empty>\n", levelStr)
 		return err
-	case *timestamp.Timestamp:
-		t, err := ptypes.Timestamp(xvalue)
+	case proto.Message:
+		dmsg, err := dynamic.AsDynamicMessage(xvalue)
 		if err != nil {
 			return err
 		}
-		_, err = fmt.Fprintf(d.Out, "%s%s\n", levelStr, t.Format(time.RFC3339))
-		return err
+		return d.DumpMessage(dmh, level, dmsg)
 	}
 
 	return fmt.Errorf("Unknown message type, cannot output")
@@ -320,7 +409,18 @@ func (d *DefaultInvokeOutput) DumpMessage(dmh *DynMsgHelper, level int, msg *dyn
 	levelStr := strings.Repeat("\t", level)
 
 	for _, fld := range msg.GetKnownFields() {
-		var value string
+		is_message := fld.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE || fld.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP
+
+		if (is_message && !fld.IsRepeated() || fld.GetOneOf() != nil) && !msg.HasField(fld) {
+			continue
+		}
+
+		var opt string
+		if fld.IsMap() {
+			opt = "[map]"
+		} else if fld.IsRepeated() {
+			opt = "[]"
+		}
 
 		// check if has getter plugin
 		has_getter, getter_value, err := dmh.GetFieldValue(msg, fld)
@@ -328,92 +428,310 @@ func (d *DefaultInvokeOutput) DumpMessage(dmh *DynMsgHelper, level int, msg *dyn
 			return err
 		}
 		if has_getter {
-			value = getter_value
-		} else {
-			switch fld.GetType() {
-			case descriptor.FieldDescriptorProto_TYPE_STRING:
-				value = msg.GetField(fld).(string)
-				// INT32
-			case descriptor.FieldDescriptorProto_TYPE_SFIXED32,
-				descriptor.FieldDescriptorProto_TYPE_INT32,
-				descriptor.FieldDescriptorProto_TYPE_SINT32,
-				descriptor.FieldDescriptorProto_TYPE_ENUM:
-				value = fmt.Sprintf("%d", msg.GetField(fld).(int32))
-				// INT64
-			case descriptor.FieldDescriptorProto_TYPE_SFIXED64,
-				descriptor.FieldDescriptorProto_TYPE_INT64,
-				descriptor.FieldDescriptorProto_TYPE_SINT64:
-				value = fmt.Sprintf("%d", msg.GetField(fld).(int64))
-				// UINT32
-			case descriptor.FieldDescriptorProto_TYPE_FIXED32,
-				descriptor.FieldDescriptorProto_TYPE_UINT32:
-				value = fmt.Sprintf("%d", msg.GetField(fld).(uint32))
-				// UINT64
-			case descriptor.FieldDescriptorProto_TYPE_FIXED64,
-				descriptor.FieldDescriptorProto_TYPE_UINT64:
-				value = fmt.Sprintf("%d", msg.GetField(fld).(uint64))
-				// FLOAT32
-			case descriptor.FieldDescriptorProto_TYPE_FLOAT:
-				value = fmt.Sprintf("%f", msg.GetField(fld).(float32))
-				// FLOAT64
-			case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
-				value = fmt.Sprintf("%f", msg.GetField(fld).(float64))
-				// BOOL
-			case descriptor.FieldDescriptorProto_TYPE_BOOL:
-				value = fmt.Sprintf("%v", msg.GetField(fld).(bool))
-			case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
-				value = ""
-			default:
-				value = "Unknown"
+			_, err = fmt.Fprintf(d.Out, "%s%s%s: %s\n", levelStr, fld.GetName(), opt, getter_value)
+			if err != nil {
+				return err
 			}
+			continue
 		}
 
-		is_print := true
-		if fld.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE && !msg.HasField(fld) {
-			is_print = false
-		}
-
-		if is_print {
-			var opt string
-			if fld.IsMap() {
-				opt = "[map]"
-			} else if fld.IsRepeated() {
-				opt = "[]"
+		if fld.IsMap() {
+			// map fields have value of map[interface{}]interface{}
+			_, err = fmt.Fprintf(d.Out, "%s%s%s:\n", levelStr, fld.GetName(), opt)
+			if err != nil {
+				return err
 			}
 
-			fmt.Fprintf(d.Out, "%s%s%s: %s\n", levelStr, fld.GetName(), opt, value)
-
-			if !has_getter {
-				// Dump sub messages
-				switch fld.GetType() {
-				case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
-					if fld.IsMap() {
-						// map fields have value of map[interface{}]interface{}
-						f_map := msg.GetField(fld).(map[interface{}]interface{})
-
-						for ridx, ritem := range f_map {
-							fmt.Fprintf(d.Out, "%s\t- %v\n", levelStr, ridx)
-							err := d.DumpMessageCheck(dmh, level+1, ritem)
-							if err != nil {
-								return err
-							}
-						}
-					} else if fld.IsRepeated() {
-						for ridx := 0; ridx < msg.FieldLength(fld); ridx++ {
-							fmt.Fprintf(d.Out, "%s\t-\n", levelStr)
-							err := d.DumpMessageCheck(dmh, level+1, msg.GetRepeatedField(fld, ridx))
-							if err != nil {
-								return err
-							}
+			f_map := msg.GetField(fld).(map[interface{}]interface{})
+			var keys []interface{}
+			for k := range f_map {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return mapKeyLess(keys[i], keys[j])
+			})
+
+			valueFld := fld.GetMapValueType()
+			is_message_value := valueFld.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE || valueFld.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP
+			for _, k := range keys {
+				if is_message_value {
+					var is_wkt bool
+					var wkt string
+					is_wkt, wkt, err = d.formatWellKnown(dmh, f_map[k])
+					if err != nil {
+						return err
+					}
+					if is_wkt {
+						_, err = fmt.Fprintf(d.Out, "%s\t- %v: %s\n", levelStr, k, wkt)
+					} else {
+						_, err = fmt.Fprintf(d.Out, "%s\t- %v\n", levelStr, k)
+						if err == nil {
+							err = d.DumpMessageCheck(dmh, level+1, f_map[k])
 						}
+					}
+				} else {
+					_, err = fmt.Fprintf(d.Out, "%s\t- %v: %s\n", levelStr, k, d.formatScalar(valueFld, f_map[k]))
+				}
+				if err != nil {
+					return err
+				}
+			}
+		} else if fld.IsRepeated() {
+			_, err = fmt.Fprintf(d.Out, "%s%s%s:\n", levelStr, fld.GetName(), opt)
+			if err != nil {
+				return err
+			}
+
+			for ridx := 0; ridx < msg.FieldLength(fld); ridx++ {
+				if is_message {
+					var is_wkt bool
+					var wkt string
+					is_wkt, wkt, err = d.formatWellKnown(dmh, msg.GetRepeatedField(fld, ridx))
+					if err != nil {
+						return err
+					}
+					if is_wkt {
+						_, err = fmt.Fprintf(d.Out, "%s\t- %s\n", levelStr, wkt)
 					} else {
-						err := d.DumpMessageCheck(dmh, level+1, msg.GetField(fld))
-						if err != nil {
-							return err
+						_, err = fmt.Fprintf(d.Out, "%s\t-\n", levelStr)
+						if err == nil {
+							err = d.DumpMessageCheck(dmh, level+1, msg.GetRepeatedField(fld, ridx))
 						}
 					}
+				} else {
+					_, err = fmt.Fprintf(d.Out, "%s\t- %s\n", levelStr, d.formatScalar(fld, msg.GetRepeatedField(fld, ridx)))
+				}
+				if err != nil {
+					return err
+				}
+			}
+		} else if is_message {
+			is_wkt, wkt, err := d.formatWellKnown(dmh, msg.GetField(fld))
+			if err != nil {
+				return err
+			}
+			if is_wkt {
+				_, err = fmt.Fprintf(d.Out, "%s%s: %s\n", levelStr, fld.GetName(), wkt)
+			} else {
+				_, err = fmt.Fprintf(d.Out, "%s%s:\n", levelStr, fld.GetName())
+				if err == nil {
+					err = d.DumpMessageCheck(dmh, level+1, msg.GetField(fld))
 				}
 			}
+			if err != nil {
+				return err
+			}
+		} else {
+			_, err = fmt.Fprintf(d.Out, "%s%s: %s\n", levelStr, fld.GetName(), d.formatScalar(fld, msg.GetField(fld)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Formats a well-known type message in a single line, with Struct, ListValue and Any as JSON
+func (d *DefaultInvokeOutput) formatWellKnown(dmh *DynMsgHelper, msg interface{}) (ok bool, value string, err error) {
+	pmsg, ok := msg.(proto.Message)
+	if !ok {
+		return false, "", nil
+	}
+
+	is_wkt, wv, err := dmh.WellKnownTypeValue(pmsg)
+	if err != nil || !is_wkt {
+		return false, "", err
+	}
+
+	switch xvalue := wv.(type) {
+	case []*MapEntryValue, []interface{}, *AnyValue, nil:
+		b, err := json.Marshal(TemplateValue(xvalue))
+		if err != nil {
+			return false, "", err
+		}
+		return true, string(b), nil
+	case []byte:
+		return true, d.BytesEncoding.Encode(xvalue), nil
+	}
+	return true, formatScalarValue(wv), nil
+}
+
+// Formats a single scalar value of a field. Enums are output as "NAME (number)".
+func (d *DefaultInvokeOutput) formatScalar(fld *desc.FieldDescriptor, value interface{}) string {
+	switch xvalue := value.(type) {
+	case []byte:
+		return d.BytesEncoding.Encode(xvalue)
+	case int32:
+		if fld.GetType() == descriptor.FieldDescriptorProto_TYPE_ENUM {
+			if evd := fld.GetEnumType().FindValueByNumber(xvalue); evd != nil {
+				return fmt.Sprintf("%s (%d)", evd.GetName(), xvalue)
+			}
+		}
+	}
+	return formatScalarValue(value)
+}
+
+//
+// InvokeStreamOutput - Adapter
+//
+// Adapts an InvokeOutput to be called once per message of a streaming response.
+//
+type InvokeStreamOutputAdapter struct {
+	Output InvokeOutput
+}
+
+func NewInvokeStreamOutputAdapter(output InvokeOutput) *InvokeStreamOutputAdapter {
+	return &InvokeStreamOutputAdapter{
+		Output: output,
+	}
+}
+
+func (d *InvokeStreamOutputAdapter) OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error {
+	return nil
+}
+
+func (d *InvokeStreamOutputAdapter) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	return d.Output.OutputInvoke(dmh, value)
+}
+
+func (d *InvokeStreamOutputAdapter) OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	return nil
+}
+
+//
+// InvokeMetadataOutput
+//
+// Outputs the request metadata and response headers before the response, and the trailers and status after it,
+// similar to "curl -i".
+//
+type DefaultInvokeMetadataOutput struct {
+	Out io.Writer
+}
+
+func NewDefaultInvokeMetadataOutput(out io.Writer) *DefaultInvokeMetadataOutput {
+	return &DefaultInvokeMetadataOutput{
+		Out: out,
+	}
+}
+
+func (d *DefaultInvokeMetadataOutput) OutputInvokeHeaders(dmh *DynMsgHelper, method *desc.MethodDescriptor, requestMetadata metadata.MD, headers metadata.MD) error {
+	_, err := fmt.Fprintf(d.Out, "Method: %s\n\n", method.GetFullyQualifiedName())
+	if err != nil {
+		return err
+	}
+
+	err = d.dumpMetadata("Request metadata", requestMetadata)
+	if err != nil {
+		return err
+	}
+
+	err = d.dumpMetadata("Response headers", headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(d.Out, "Response:")
+	return err
+}
+
+func (d *DefaultInvokeMetadataOutput) OutputInvokeTrailers(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	_, err := fmt.Fprintln(d.Out)
+	if err != nil {
+		return err
+	}
+
+	err = d.dumpMetadata("Response trailers", trailers)
+	if err != nil {
+		return err
+	}
+
+	if st.Message() != "" {
+		_, err = fmt.Fprintf(d.Out, "Status: %s: %s\n", st.Code().String(), st.Message())
+	} else {
+		_, err = fmt.Fprintf(d.Out, "Status: %s\n", st.Code().String())
+	}
+	return err
+}
+
+func (d *DefaultInvokeMetadataOutput) dumpMetadata(title string, md metadata.MD) error {
+	_, err := fmt.Fprintf(d.Out, "%s:\n", title)
+	if err != nil {
+		return err
+	}
+
+	if len(md) == 0 {
+		_, err = fmt.Fprintln(d.Out, "(empty)")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, k := range sortedMetadataKeys(md) {
+		for _, v := range md[k] {
+			if strings.HasSuffix(k, "-bin") {
+				// binary values
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			_, err = fmt.Fprintf(d.Out, "%s: %s\n", k, v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintln(d.Out)
+	return err
+}
+
+//
+// InvokeErrorOutput
+//
+// Outputs the details of the status of a failed invoke. Nothing is output if there are no details, as the error
+// itself is returned by Invoke.
+//
+type DefaultInvokeErrorOutput struct {
+	Out io.Writer
+}
+
+func NewDefaultInvokeErrorOutput(out io.Writer) *DefaultInvokeErrorOutput {
+	return &DefaultInvokeErrorOutput{
+		Out: out,
+	}
+}
+
+func (d *DefaultInvokeErrorOutput) OutputInvokeError(dmh *DynMsgHelper, st *status.Status, details []proto.Message) error {
+	if len(details) == 0 {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(d.Out, "Error details:")
+	if err != nil {
+		return err
+	}
+
+	// details are output in the protobuf text format
+	dump := NewTextInvokeOutput(d.Out)
+	for _, detail := range details {
+		if xdetail, ok := detail.(*any.Any); ok {
+			_, err = fmt.Fprintf(d.Out, "- %s (unknown type)\n  %s\n", xdetail.GetTypeUrl(), base64.StdEncoding.EncodeToString(xdetail.GetValue()))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		mv, err := dmh.MessageValue(detail)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(d.Out, "- %s\n", mv.Descriptor.GetFullyQualifiedName())
+		if err != nil {
+			return err
+		}
+		err = dump.DumpMessage(1, mv)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,288 @@
+package grpcget
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Scalar types, each has a message named S_<type> with a single field "v"
+var testScalarTypes = []string{"string", "bytes", "bool", "int32", "int64", "uint32", "uint64", "sint32", "sint64",
+	"fixed32", "fixed64", "sfixed32", "sfixed64", "float", "double"}
+
+const testProto = `
+syntax = "proto3";
+package test;
+
+import "google/protobuf/any.proto";
+import "google/protobuf/duration.proto";
+import "google/protobuf/field_mask.proto";
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+
+enum Status {
+  STATUS_UNKNOWN = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_NOT_ACTIVE = 2;
+}
+
+message Inner {
+  string name = 1;
+  int32 num = 2;
+}
+
+message E_enum { Status v = 1; }
+message R_int32 { repeated int32 v = 1; }
+message R_string { repeated string v = 1; }
+message R_enum { repeated Status v = 1; }
+message R_message { repeated Inner v = 1; }
+message M_string_int32 { map<string, int32> v = 1; }
+message M_int32_string { map<int32, string> v = 1; }
+message M_string_enum { map<string, Status> v = 1; }
+message M_string_message { map<string, Inner> v = 1; }
+message N_message { Inner v = 1; }
+message O_oneof {
+  oneof o {
+    string a = 1;
+    int32 b = 2;
+  }
+}
+message W_timestamp { google.protobuf.Timestamp v = 1; }
+message W_duration { google.protobuf.Duration v = 1; }
+message W_struct { google.protobuf.Struct v = 1; }
+message W_value { google.protobuf.Value v = 1; }
+message W_list { google.protobuf.ListValue v = 1; }
+message W_fieldmask { google.protobuf.FieldMask v = 1; }
+message W_int64 { google.protobuf.Int64Value v = 1; }
+message W_string { google.protobuf.StringValue v = 1; }
+message W_bytes { google.protobuf.BytesValue v = 1; }
+message W_any { google.protobuf.Any v = 1; }
+message W_anys { repeated google.protobuf.Any v = 1; }
+
+message Item {
+  string name = 1;
+  Status state = 2;
+}
+message Items { repeated Item items = 1; }
+
+enum HTTPCode {
+  HTTP_CODE_UNKNOWN = 0;
+  HTTP_CODE_OK = 200;
+  HTTP_CODE_NOT_FOUND = 404;
+}
+message Record {
+  string name = 1;
+  Inner inner = 2;
+  repeated Inner inners = 3;
+  repeated string tags = 4;
+  map<string, int32> counts = 5;
+  Status status = 6;
+  bytes data = 7;
+  double ratio = 8;
+  google.protobuf.Timestamp created = 9;
+}
+message Rows {
+  repeated Record records = 1;
+  repeated Inner others = 2;
+}
+message Nested {
+  message Child { int32 v = 1; }
+  enum Kind { KIND_UNKNOWN = 0; KIND_A = 1; }
+  Child child = 1;
+  Kind kind = 2;
+  string json_field = 3 [json_name = "custom"];
+  map<string, Child> children = 4;
+  oneof choice {
+    string a = 5;
+    int32 b = 6;
+  }
+}
+
+service RecordService {
+  rpc Get(Item) returns (Record);
+  rpc Watch(Item) returns (stream Record);
+  rpc Upload(stream Record) returns (Items);
+  rpc Sync(stream Record) returns (stream Record);
+}
+message Params {
+  repeated string tags = 1;
+  map<string, string> labels = 2;
+  HTTPCode code = 3;
+  repeated Inner inners = 4;
+  map<string, Inner> named = 5;
+}
+`
+
+// Parses the test proto, with the scalar type messages
+func testFileDescriptor(t *testing.T) *desc.FileDescriptor {
+	t.Helper()
+
+	source := testProto
+	for _, st := range testScalarTypes {
+		source += fmt.Sprintf("message S_%s { %s v = 1; }\n", st, st)
+	}
+
+	p := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{"test.proto": source}),
+	}
+	fds, err := p.ParseFiles("test.proto")
+	if err != nil {
+		t.Fatalf("Error parsing test proto: %v", err)
+	}
+	return fds[0]
+}
+
+// Creates an empty message of the test proto
+func testMessage(t *testing.T, fd *desc.FileDescriptor, name string) *dynamic.Message {
+	t.Helper()
+
+	md := fd.FindMessage("test." + name)
+	if md == nil {
+		t.Fatalf("Message %s not found in test proto", name)
+	}
+	return dynamic.NewMessage(md)
+}
+
+func TestDefaultInvokeOutput(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	tests := []struct {
+		name     string
+		message  string
+		value    interface{}
+		encoding BytesEncoding
+		want     string
+	}{
+		{name: "string", message: "S_string", value: "hello", want: "v: hello\n"},
+		{name: "string default", message: "S_string", want: "v: \n"},
+		{name: "bytes base64", message: "S_bytes", value: []byte("hi\x00"), want: "v: aGkA\n"},
+		{name: "bytes hex", message: "S_bytes", value: []byte("hi\x00"), encoding: BytesHex, want: "v: 686900\n"},
+		{name: "bytes text", message: "S_bytes", value: []byte("hi"), encoding: BytesText, want: "v: hi\n"},
+		{name: "bytes text binary", message: "S_bytes", value: []byte("hi\x00"), encoding: BytesText, want: "v: base64:aGkA\n"},
+		{name: "bool", message: "S_bool", value: true, want: "v: true\n"},
+		{name: "int32", message: "S_int32", value: int32(-5), want: "v: -5\n"},
+		{name: "int32 default", message: "S_int32", want: "v: 0\n"},
+		{name: "int64", message: "S_int64", value: int64(-9007199254740993), want: "v: -9007199254740993\n"},
+		{name: "uint32", message: "S_uint32", value: uint32(4294967295), want: "v: 4294967295\n"},
+		{name: "uint64", message: "S_uint64", value: uint64(18446744073709551615), want: "v: 18446744073709551615\n"},
+		{name: "sint32", message: "S_sint32", value: int32(-7), want: "v: -7\n"},
+		{name: "sint64", message: "S_sint64", value: int64(-7), want: "v: -7\n"},
+		{name: "fixed32", message: "S_fixed32", value: uint32(7), want: "v: 7\n"},
+		{name: "fixed64", message: "S_fixed64", value: uint64(7), want: "v: 7\n"},
+		{name: "sfixed32", message: "S_sfixed32", value: int32(-7), want: "v: -7\n"},
+		{name: "sfixed64", message: "S_sfixed64", value: int64(-7), want: "v: -7\n"},
+		{name: "float", message: "S_float", value: float32(1.1), want: "v: 1.1\n"},
+		{name: "double", message: "S_double", value: float64(2.5e-10), want: "v: 2.5e-10\n"},
+		{name: "enum", message: "E_enum", value: int32(1), want: "v: STATUS_ACTIVE (1)\n"},
+		{name: "enum unknown number", message: "E_enum", value: int32(7), want: "v: 7\n"},
+		{name: "repeated int32", message: "R_int32", value: []interface{}{int32(1), int32(2)}, want: "v[]:\n\t- 1\n\t- 2\n"},
+		{name: "repeated string", message: "R_string", value: []interface{}{"a", "b"}, want: "v[]:\n\t- a\n\t- b\n"},
+		{name: "repeated enum", message: "R_enum", value: []interface{}{int32(2), int32(5)}, want: "v[]:\n\t- STATUS_NOT_ACTIVE (2)\n\t- 5\n"},
+		{name: "repeated message", message: "R_message",
+			value: []interface{}{testInner(t, fd, "a"), testInner(t, fd, "b")},
+			want:  "v[]:\n\t-\n\tname: a\n\tnum: 0\n\t-\n\tname: b\n\tnum: 0\n"},
+		{name: "map sorted string keys", message: "M_string_int32",
+			value: map[interface{}]interface{}{"b": int32(2), "a": int32(1), "c": int32(3)},
+			want:  "v[map]:\n\t- a: 1\n\t- b: 2\n\t- c: 3\n"},
+		{name: "map sorted int keys", message: "M_int32_string",
+			value: map[interface{}]interface{}{int32(10): "x", int32(2): "y", int32(-1): "z"},
+			want:  "v[map]:\n\t- -1: z\n\t- 2: y\n\t- 10: x\n"},
+		{name: "map enum values", message: "M_string_enum",
+			value: map[interface{}]interface{}{"a": int32(1), "b": int32(9)},
+			want:  "v[map]:\n\t- a: STATUS_ACTIVE (1)\n\t- b: 9\n"},
+		{name: "map message values", message: "M_string_message",
+			value: map[interface{}]interface{}{"k": testInner(t, fd, "n")},
+			want:  "v[map]:\n\t- k\n\tname: n\n\tnum: 0\n"},
+		{name: "message", message: "N_message", value: testInner(t, fd, "n"), want: "v:\n\tname: n\n\tnum: 0\n"},
+		{name: "message unset", message: "N_message", want: ""},
+		{name: "timestamp", message: "W_timestamp", value: timestamppb.New(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), want: "v: 2020-01-02T03:04:05Z\n"},
+		{name: "duration", message: "W_duration", value: durationpb.New(1500 * time.Millisecond), want: "v: 1.5s\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage(t, fd, tt.message)
+			if tt.value != nil {
+				err := msg.TrySetFieldByName("v", tt.value)
+				if err != nil {
+					t.Fatalf("Error setting value: %v", err)
+				}
+			}
+
+			var b bytes.Buffer
+			output := NewDefaultInvokeOutput(&b)
+			output.BytesEncoding = tt.encoding
+			err := output.OutputInvoke(NewDynMsgHelper(), msg)
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultInvokeOutputOneof(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	msg := testMessage(t, fd, "O_oneof")
+	msg.SetFieldByName("b", int32(3))
+
+	var b bytes.Buffer
+	err := NewDefaultInvokeOutput(&b).OutputInvoke(NewDynMsgHelper(), msg)
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+	// only the set member of the oneof is output
+	if want := "b: 3\n"; b.String() != want {
+		t.Errorf("Output is %q, want %q", b.String(), want)
+	}
+}
+
+func testInner(t *testing.T, fd *desc.FileDescriptor, name string) *dynamic.Message {
+	inner := testMessage(t, fd, "Inner")
+	inner.SetFieldByName("name", name)
+	return inner
+}
+
+// Creates a Record message with all fields set, for the output tests
+func testRecord(t *testing.T, fd *desc.FileDescriptor) *dynamic.Message {
+	t.Helper()
+
+	inner := testInner(t, fd, "in")
+	inner.SetFieldByName("num", int32(2))
+	first := testInner(t, fd, "x")
+	first.SetFieldByName("num", int32(1))
+
+	msg := testMessage(t, fd, "Record")
+	msg.SetFieldByName("name", `a "b"`)
+	msg.SetFieldByName("inner", inner)
+	msg.SetFieldByName("inners", []interface{}{first, testInner(t, fd, "y")})
+	msg.SetFieldByName("tags", []interface{}{"a", "b"})
+	msg.SetFieldByName("counts", map[interface{}]interface{}{"b": int32(2), "a": int32(1)})
+	msg.SetFieldByName("status", int32(1))
+	msg.SetFieldByName("data", []byte("hi\x00"))
+	msg.SetFieldByName("ratio", float64(0.5))
+	msg.SetFieldByName("created", timestamppb.New(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	return msg
+}
+
+// Field getter that outputs Inner messages as "name/num"
+type testInnerGetter struct{}
+
+func (g *testInnerGetter) GetFieldValue(msg *dynamic.Message, fld *desc.FieldDescriptor) (ok bool, value string, err error) {
+	if fld.GetMessageType() == nil || fld.GetMessageType().GetFullyQualifiedName() != "test.Inner" || fld.IsRepeated() {
+		return false, "", nil
+	}
+	inner := msg.GetField(fld).(*dynamic.Message)
+	return true, fmt.Sprintf("%s/%d", inner.GetFieldByName("name"), inner.GetFieldByName("num")), nil
+}
@@ -0,0 +1,323 @@
+package grpcget
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc"
+)
+
+//
+// Descriptor cache
+//
+// Stores the descriptors of all services of a server on disk, keyed by the target address. Each target has a
+// protoset file with the descriptors, and a JSON file with the cache metadata.
+// The cache is refreshed when it expires, when the list of services of the server changes, or when a symbol
+// is not found in it.
+//
+
+// Returns the default directory of the descriptor cache, inside the user cache directory
+func DefaultDescriptorCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "grpcget", "descriptors"), nil
+}
+
+// Removes the cached descriptors of the target, or of all targets if target is blank
+func ClearDescriptorCache(dir string, target string) error {
+	if target != "" {
+		key := descriptorCacheKey(target)
+		for _, fn := range []string{key + ".protoset", key + ".json"} {
+			err := os.Remove(filepath.Join(dir, fn))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		// only remove files named by descriptorCacheKey
+		name := strings.TrimSuffix(strings.TrimSuffix(f.Name(), ".protoset"), ".json")
+		if !f.IsDir() && name != f.Name() && len(name) == sha256.Size*2 {
+			err := os.Remove(filepath.Join(dir, f.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Cache metadata
+type descriptorCacheInfo struct {
+	Target       string    `json:"target"`
+	Created      time.Time `json:"created"`
+	Services     []string  `json:"services"`
+	ServicesHash string    `json:"services_hash"`
+}
+
+func descriptorCacheKey(target string) string {
+	h := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(h[:])
+}
+
+func descriptorServicesHash(services []string) string {
+	sorted := append([]string(nil), services...)
+	sort.Strings(sorted)
+	h := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(h[:])
+}
+
+//
+// DescriptorSourceSupplier - Cache
+//
+// Caches the descriptors of another DescriptorSourceSupplier, usually the reflection.
+//
+type CacheDescriptorSourceSupplier struct {
+	Supplier DescriptorSourceSupplier
+	Dir      string
+	// Time after which the cache is refreshed, 0 never expires
+	TTL time.Duration
+	// Refresh the cache even if it is still valid
+	Refresh bool
+}
+
+func NewCacheDescriptorSourceSupplier(supplier DescriptorSourceSupplier, dir string, ttl time.Duration) *CacheDescriptorSourceSupplier {
+	return &CacheDescriptorSourceSupplier{
+		Supplier: supplier,
+		Dir:      dir,
+		TTL:      ttl,
+	}
+}
+
+func (s *CacheDescriptorSourceSupplier) GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error) {
+	conn, err := getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.Supplier.GetDescriptorSource(ctx, getConn)
+	if err != nil {
+		return nil, err
+	}
+
+	key := descriptorCacheKey(conn.Target())
+	ret := &CacheDescriptorSource{
+		Source:       source,
+		target:       conn.Target(),
+		infoFile:     filepath.Join(s.Dir, key+".json"),
+		protosetFile: filepath.Join(s.Dir, key+".protoset"),
+	}
+
+	err = ret.load(s.TTL, s.Refresh)
+	if err != nil {
+		ret.Close()
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+//
+// DescriptorSource - Cache
+//
+type CacheDescriptorSource struct {
+	Source DescriptorSource
+
+	target       string
+	infoFile     string
+	protosetFile string
+	services     []string
+	files        *FileDescriptorSource
+	refreshed    bool
+}
+
+// Loads the cache, or refreshes it if it is not valid
+func (s *CacheDescriptorSource) load(ttl time.Duration, refresh bool) error {
+	if !refresh {
+		ok, err := s.loadCache(ttl)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return s.refresh()
+}
+
+// Loads the cache files, returning false if they don't exist or are not valid
+func (s *CacheDescriptorSource) loadCache(ttl time.Duration) (bool, error) {
+	infob, err := ioutil.ReadFile(s.infoFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var info descriptorCacheInfo
+	if err := json.Unmarshal(infob, &info); err != nil || info.Target != s.target {
+		// invalid cache
+		return false, nil
+	}
+
+	if ttl > 0 && time.Since(info.Created) > ttl {
+		return false, nil
+	}
+
+	// check if the services of the server changed
+	services, err := s.Source.ListServices()
+	if err != nil {
+		return false, err
+	}
+	if descriptorServicesHash(services) != info.ServicesHash {
+		return false, nil
+	}
+
+	files, err := NewProtosetDescriptorSource(s.protosetFile)
+	if err != nil {
+		// invalid cache
+		return false, nil
+	}
+
+	s.services = info.Services
+	s.files = files
+	return true, nil
+}
+
+// Loads all descriptors from the source and writes the cache files
+func (s *CacheDescriptorSource) refresh() error {
+	services, err := s.Source.ListServices()
+	if err != nil {
+		return err
+	}
+
+	files, err := collectServiceFiles(s.Source, services)
+	if err != nil {
+		return err
+	}
+
+	s.services = services
+	s.files = NewFileDescriptorSource(files...)
+	s.refreshed = true
+
+	return s.write(fileDescriptorSet(files))
+}
+
+func (s *CacheDescriptorSource) write(fds *descriptor.FileDescriptorSet) error {
+	err := os.MkdirAll(filepath.Dir(s.infoFile), 0755)
+	if err != nil {
+		return fmt.Errorf("Error creating descriptor cache directory: %v", err)
+	}
+
+	fdsb, err := proto.Marshal(fds)
+	if err != nil {
+		return err
+	}
+
+	infob, err := json.Marshal(&descriptorCacheInfo{
+		Target:       s.target,
+		Created:      time.Now(),
+		Services:     s.services,
+		ServicesHash: descriptorServicesHash(s.services),
+	})
+	if err != nil {
+		return err
+	}
+
+	// write the protoset first, the info file validates it
+	err = writeFileAtomic(s.protosetFile, fdsb)
+	if err != nil {
+		return fmt.Errorf("Error writing descriptor cache: %v", err)
+	}
+	err = writeFileAtomic(s.infoFile, infob)
+	if err != nil {
+		return fmt.Errorf("Error writing descriptor cache: %v", err)
+	}
+
+	return nil
+}
+
+func (s *CacheDescriptorSource) ListServices() ([]string, error) {
+	return s.services, nil
+}
+
+func (s *CacheDescriptorSource) FindSymbol(symbol string) (desc.Descriptor, error) {
+	d, err := s.files.FindSymbol(symbol)
+	if err == nil {
+		return d, nil
+	}
+
+	// the symbol may be new on the server, refresh the cache once
+	if !s.refreshed {
+		err = s.refresh()
+		if err != nil {
+			return nil, err
+		}
+		if d, err := s.files.FindSymbol(symbol); err == nil {
+			return d, nil
+		}
+	}
+
+	// the symbol may not be in any service file, ask the source
+	return s.Source.FindSymbol(symbol)
+}
+
+func (s *CacheDescriptorSource) FindFile(fileName string) (*desc.FileDescriptor, error) {
+	fd, err := s.files.FindFile(fileName)
+	if err == nil {
+		return fd, nil
+	}
+	return s.Source.FindFile(fileName)
+}
+
+func (s *CacheDescriptorSource) Close() error {
+	if c, ok := s.Source.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Writes a file using a temporary file and renaming it, so readers never see a partial file
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,443 @@
+package grpcget
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+//
+// Descriptor sources
+//
+// Descriptor sources that implement io.Closer are closed by GrpcGet after use.
+//
+
+//
+// DescriptorSource - Reflection
+//
+type ReflectionDescriptorSource struct {
+	Client *grpcreflect.Client
+}
+
+func NewReflectionDescriptorSource(client *grpcreflect.Client) *ReflectionDescriptorSource {
+	return &ReflectionDescriptorSource{
+		Client: client,
+	}
+}
+
+func (s *ReflectionDescriptorSource) ListServices() ([]string, error) {
+	return s.Client.ListServices()
+}
+
+func (s *ReflectionDescriptorSource) FindSymbol(symbol string) (desc.Descriptor, error) {
+	file, err := s.Client.FileContainingSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	d := file.FindSymbol(symbol)
+	if d == nil {
+		return nil, fmt.Errorf("Symbol %s not found", symbol)
+	}
+	return d, nil
+}
+
+func (s *ReflectionDescriptorSource) FindFile(fileName string) (*desc.FileDescriptor, error) {
+	return s.Client.FileByFilename(fileName)
+}
+
+func (s *ReflectionDescriptorSource) Close() error {
+	s.Client.Reset()
+	return nil
+}
+
+// Order in which the versions of the reflection service are tried
+type ReflectionVersionOrder int
+
+const (
+	// Try grpc.reflection.v1 first, falling back to grpc.reflection.v1alpha
+	ReflectionV1First ReflectionVersionOrder = iota
+	// Try grpc.reflection.v1alpha first, falling back to grpc.reflection.v1
+	ReflectionV1AlphaFirst
+)
+
+//
+// DescriptorSourceSupplier - Reflection
+//
+// Supports both grpc.reflection.v1 and grpc.reflection.v1alpha, using the one the server supports.
+//
+type ReflectionDescriptorSourceSupplier struct {
+	VersionOrder ReflectionVersionOrder
+}
+
+func NewReflectionDescriptorSourceSupplier() *ReflectionDescriptorSourceSupplier {
+	return &ReflectionDescriptorSourceSupplier{
+		VersionOrder: ReflectionV1First,
+	}
+}
+
+func (s *ReflectionDescriptorSourceSupplier) GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error) {
+	conn, err := getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.VersionOrder == ReflectionV1AlphaFirst {
+		client := grpcreflect.NewClientV1Alpha(ctx, grpc_reflection_v1alpha.NewServerReflectionClient(conn))
+
+		// check if the server supports v1alpha
+		_, err := client.ListServices()
+		if err == nil {
+			return NewReflectionDescriptorSource(client), nil
+		}
+		client.Reset()
+		if status.Code(err) != codes.Unimplemented {
+			return nil, err
+		}
+	}
+
+	// tries v1 first, and falls back to v1alpha if the server returns Unimplemented
+	return NewReflectionDescriptorSource(grpcreflect.NewClientAuto(ctx, conn)), nil
+}
+
+//
+// DescriptorSource - File
+//
+// Uses a list of file descriptors. It is also a DescriptorSourceSupplier that supplies itself.
+//
+type FileDescriptorSource struct {
+	Files []*desc.FileDescriptor
+}
+
+func NewFileDescriptorSource(files ...*desc.FileDescriptor) *FileDescriptorSource {
+	return &FileDescriptorSource{
+		Files: files,
+	}
+}
+
+// Parses .proto source files. The file names are relative to the import paths, if any.
+func NewProtoFileDescriptorSource(importPaths []string, fileNames ...string) (*FileDescriptorSource, error) {
+	p := protoparse.Parser{
+		ImportPaths:           importPaths,
+		IncludeSourceCodeInfo: true,
+	}
+	files, err := p.ParseFiles(fileNames...)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing proto files: %v", err)
+	}
+
+	return NewFileDescriptorSource(files...), nil
+}
+
+// Reads protoset files, which are binary encoded FileDescriptorSet messages, like the ones created by
+// "protoc --include_imports --descriptor_set_out".
+func NewProtosetDescriptorSource(fileNames ...string) (*FileDescriptorSource, error) {
+	fds := &descriptor.FileDescriptorSet{}
+	for _, fileName := range fileNames {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading protoset file %s: %v", fileName, err)
+		}
+
+		var fileFds descriptor.FileDescriptorSet
+		err = proto.Unmarshal(b, &fileFds)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing protoset file %s: %v", fileName, err)
+		}
+
+		fds.File = append(fds.File, fileFds.File...)
+	}
+
+	return NewFileDescriptorSetDescriptorSource(fds)
+}
+
+// Uses the files of a FileDescriptorSet, which must contain all dependencies.
+func NewFileDescriptorSetDescriptorSource(fds *descriptor.FileDescriptorSet) (*FileDescriptorSource, error) {
+	// remove duplicates, the same dependency can be in more than one set
+	unique := &descriptor.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	for _, fd := range fds.File {
+		if !seen[fd.GetName()] {
+			seen[fd.GetName()] = true
+			unique.File = append(unique.File, fd)
+		}
+	}
+
+	filemap, err := desc.CreateFileDescriptorsFromSet(unique)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating descriptors from file descriptor set: %v", err)
+	}
+
+	// keep the order of the set
+	var files []*desc.FileDescriptor
+	for _, fd := range unique.File {
+		files = append(files, filemap[fd.GetName()])
+	}
+
+	return NewFileDescriptorSource(files...), nil
+}
+
+func (s *FileDescriptorSource) ListServices() ([]string, error) {
+	var services []string
+	for _, fd := range s.Files {
+		for _, svc := range fd.GetServices() {
+			services = append(services, svc.GetFullyQualifiedName())
+		}
+	}
+	return services, nil
+}
+
+func (s *FileDescriptorSource) FindSymbol(symbol string) (desc.Descriptor, error) {
+	// search the files and all their dependencies
+	seen := make(map[string]bool)
+	var find func(files []*desc.FileDescriptor) desc.Descriptor
+	find = func(files []*desc.FileDescriptor) desc.Descriptor {
+		for _, fd := range files {
+			if seen[fd.GetName()] {
+				continue
+			}
+			seen[fd.GetName()] = true
+
+			if d := fd.FindSymbol(symbol); d != nil {
+				return d
+			}
+			if d := find(fd.GetDependencies()); d != nil {
+				return d
+			}
+		}
+		return nil
+	}
+
+	d := find(s.Files)
+	if d == nil {
+		return nil, fmt.Errorf("Symbol %s not found", symbol)
+	}
+	return d, nil
+}
+
+func (s *FileDescriptorSource) FindFile(fileName string) (*desc.FileDescriptor, error) {
+	// search the files and all their dependencies
+	seen := make(map[string]bool)
+	var find func(files []*desc.FileDescriptor) *desc.FileDescriptor
+	find = func(files []*desc.FileDescriptor) *desc.FileDescriptor {
+		for _, fd := range files {
+			if seen[fd.GetName()] {
+				continue
+			}
+			seen[fd.GetName()] = true
+
+			if fd.GetName() == fileName {
+				return fd
+			}
+			if d := find(fd.GetDependencies()); d != nil {
+				return d
+			}
+		}
+		return nil
+	}
+
+	fd := find(s.Files)
+	if fd == nil {
+		return nil, fmt.Errorf("File %s not found", fileName)
+	}
+	return fd, nil
+}
+
+func (s *FileDescriptorSource) GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error) {
+	return s, nil
+}
+
+//
+// DescriptorSourceSupplier - Proto files
+//
+// Parses .proto source files when the descriptor source is requested.
+//
+type ProtoFileDescriptorSourceSupplier struct {
+	ImportPaths []string
+	FileNames   []string
+}
+
+func NewProtoFileDescriptorSourceSupplier(importPaths []string, fileNames ...string) *ProtoFileDescriptorSourceSupplier {
+	return &ProtoFileDescriptorSourceSupplier{
+		ImportPaths: importPaths,
+		FileNames:   fileNames,
+	}
+}
+
+func (s *ProtoFileDescriptorSourceSupplier) GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error) {
+	return NewProtoFileDescriptorSource(s.ImportPaths, s.FileNames...)
+}
+
+//
+// DescriptorSourceSupplier - Protoset files
+//
+// Reads protoset files when the descriptor source is requested.
+//
+type ProtosetDescriptorSourceSupplier struct {
+	FileNames []string
+}
+
+func NewProtosetDescriptorSourceSupplier(fileNames ...string) *ProtosetDescriptorSourceSupplier {
+	return &ProtosetDescriptorSourceSupplier{
+		FileNames: fileNames,
+	}
+}
+
+func (s *ProtosetDescriptorSourceSupplier) GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error) {
+	return NewProtosetDescriptorSource(s.FileNames...)
+}
+
+//
+// DescriptorSource - Combined
+//
+// Lists the services of all sources, and finds symbols in the first source that has it.
+//
+type CombinedDescriptorSource struct {
+	Sources []DescriptorSource
+}
+
+func NewCombinedDescriptorSource(sources ...DescriptorSource) *CombinedDescriptorSource {
+	return &CombinedDescriptorSource{
+		Sources: sources,
+	}
+}
+
+func (s *CombinedDescriptorSource) ListServices() ([]string, error) {
+	var services []string
+	seen := make(map[string]bool)
+	for _, source := range s.Sources {
+		sservices, err := source.ListServices()
+		if err != nil {
+			return nil, err
+		}
+		for _, svc := range sservices {
+			if !seen[svc] {
+				seen[svc] = true
+				services = append(services, svc)
+			}
+		}
+	}
+	return services, nil
+}
+
+func (s *CombinedDescriptorSource) FindSymbol(symbol string) (desc.Descriptor, error) {
+	var firstErr error
+	for _, source := range s.Sources {
+		d, err := source.FindSymbol(symbol)
+		if err == nil {
+			return d, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("Symbol %s not found", symbol)
+	}
+	return nil, firstErr
+}
+
+func (s *CombinedDescriptorSource) FindFile(fileName string) (*desc.FileDescriptor, error) {
+	var firstErr error
+	for _, source := range s.Sources {
+		fd, err := source.FindFile(fileName)
+		if err == nil {
+			return fd, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("File %s not found", fileName)
+	}
+	return nil, firstErr
+}
+
+func (s *CombinedDescriptorSource) Close() error {
+	var firstErr error
+	for _, source := range s.Sources {
+		if c, ok := source.(io.Closer); ok {
+			err := c.Close()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+//
+// DescriptorSourceSupplier - Combined
+//
+type CombinedDescriptorSourceSupplier struct {
+	Suppliers []DescriptorSourceSupplier
+}
+
+func NewCombinedDescriptorSourceSupplier(suppliers ...DescriptorSourceSupplier) *CombinedDescriptorSourceSupplier {
+	return &CombinedDescriptorSourceSupplier{
+		Suppliers: suppliers,
+	}
+}
+
+func (s *CombinedDescriptorSourceSupplier) GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error) {
+	ret := NewCombinedDescriptorSource()
+	for _, supplier := range s.Suppliers {
+		source, err := supplier.GetDescriptorSource(ctx, getConn)
+		if err != nil {
+			ret.Close()
+			return nil, err
+		}
+		ret.Sources = append(ret.Sources, source)
+	}
+	return ret, nil
+}
+
+// Returns the files that define the services and all their transitive dependencies, with dependencies before
+// the files that import them
+func collectServiceFiles(source DescriptorSource, services []string) ([]*desc.FileDescriptor, error) {
+	var files []*desc.FileDescriptor
+	seen := make(map[string]bool)
+
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		files = append(files, fd)
+	}
+
+	for _, service := range services {
+		d, err := source.FindSymbol(service)
+		if err != nil {
+			return nil, fmt.Errorf("Error resolving service %s: %v", service, err)
+		}
+		add(d.GetFile())
+	}
+
+	return files, nil
+}
+
+// Creates a FileDescriptorSet from the file descriptors
+func fileDescriptorSet(files []*desc.FileDescriptor) *descriptor.FileDescriptorSet {
+	fds := &descriptor.FileDescriptorSet{}
+	for _, fd := range files {
+		fds.File = append(fds.File, fd.AsFileDescriptorProto())
+	}
+	return fds
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/RangelReale/grpcget/bytesvalue"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/golang/protobuf/ptypes/wrappers"
@@ -74,7 +75,11 @@ func (h *DMHWrappers) ParseFieldValue(fld *desc.FieldDescriptor, value string) (
 				}
 				return true, &wrappers.BoolValue{Value: v}, nil
 			case ".google.protobuf.BytesValue":
-				return true, &wrappers.BytesValue{Value: []byte(value)}, nil
+				v, err := grpcget_bytesvalue.Parse(value)
+				if err != nil {
+					return false, nil, err
+				}
+				return true, &wrappers.BytesValue{Value: v}, nil
 			}
 		}
 	}
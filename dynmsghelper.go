@@ -149,14 +149,14 @@ func (s *setParamSetter_Repeated) SetValue(val interface{}) error {
 		}
 		return s.msg.TrySetField(s.fld, []interface{}{val})
 	} else {
-		if s.msg.FieldLength(s.fld) == s.key-1 {
-			// if same as the last one, set its value
+		if s.key >= 0 && s.key < s.msg.FieldLength(s.fld) {
+			// if an existing item, set its value
 			return s.msg.TrySetRepeatedField(s.fld, s.key, val)
 		} else if s.msg.FieldLength(s.fld) == s.key {
 			// if one more that last one, add field
 			return s.msg.TryAddRepeatedField(s.fld, val)
 		} else {
-			return fmt.Errorf("Invalid index %d for repeated field, repeated fields must be set in order")
+			return fmt.Errorf("Invalid index %d for repeated field, repeated fields must be set in order", s.key)
 		}
 	}
 }
@@ -177,6 +177,10 @@ func (h *DynMsgHelper) internalSetParamValue(setter setParamSetter, fld *desc.Fi
 			if len(mfields) == 0 {
 				return fmt.Errorf("Invoke map field name must have at least 1 value, have %d", len(mfields))
 			}
+			if len(mfields) == 1 {
+				// the key is the last part of the name, like labels.env
+				mfields = append(mfields, "")
+			}
 
 			keyvalue, err := h.MustParseScalarFieldValue(fld.GetMapKeyType(), mfields[0])
 			if err != nil {
@@ -189,6 +193,10 @@ func (h *DynMsgHelper) internalSetParamValue(setter setParamSetter, fld *desc.Fi
 			if len(rfields) == 0 {
 				return fmt.Errorf("Invoke repeated field name must have at least 1 value, have %d", len(rfields))
 			}
+			if len(rfields) == 1 {
+				// the index is the last part of the name, like tags.0
+				rfields = append(rfields, "")
+			}
 
 			keyvalue, err := strconv.ParseInt(rfields[0], 10, 32)
 			if err != nil {
@@ -246,13 +254,19 @@ func (h *DynMsgHelper) ParseScalarFieldValue(fld *desc.FieldDescriptor, value st
 		// INT32
 	case descriptor.FieldDescriptorProto_TYPE_SFIXED32,
 		descriptor.FieldDescriptorProto_TYPE_INT32,
-		descriptor.FieldDescriptorProto_TYPE_SINT32,
-		descriptor.FieldDescriptorProto_TYPE_ENUM:
+		descriptor.FieldDescriptorProto_TYPE_SINT32:
 		ivalue, err := strconv.ParseInt(value, 10, 32)
 		if err != nil {
 			return true, nil, err
 		}
 		return true, int32(ivalue), nil
+		// ENUM
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		ivalue, err := ParseEnumValue(fld.GetEnumType(), value)
+		if err != nil {
+			return true, nil, err
+		}
+		return true, ivalue, nil
 		// INT64
 	case descriptor.FieldDescriptorProto_TYPE_SFIXED64,
 		descriptor.FieldDescriptorProto_TYPE_INT64,
@@ -299,27 +313,78 @@ func (h *DynMsgHelper) ParseScalarFieldValue(fld *desc.FieldDescriptor, value st
 			return true, nil, err
 		}
 		return true, ivalue, nil
+		// BYTES
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		bvalue, err := ParseBytesValue(value)
+		if err != nil {
+			return true, nil, err
+		}
+		return true, bvalue, nil
 	}
 	return false, nil, nil
 }
 
+// Parses an enum value by number or name. Names are case-insensitive, and can omit the prefix of the enum
+// name convention, like ACTIVE for the STATUS_ACTIVE value of the Status enum.
+func ParseEnumValue(enum *desc.EnumDescriptor, value string) (int32, error) {
+	if ivalue, err := strconv.ParseInt(value, 10, 32); err == nil {
+		return int32(ivalue), nil
+	}
+
+	prefix := enumValuePrefix(enum.GetName())
+	for _, ev := range enum.GetValues() {
+		if strings.EqualFold(ev.GetName(), value) || strings.EqualFold(ev.GetName(), prefix+value) {
+			return ev.GetNumber(), nil
+		}
+	}
+
+	var names []string
+	for _, ev := range enum.GetValues() {
+		names = append(names, ev.GetName())
+	}
+	return 0, fmt.Errorf("Invalid value '%s' for enum %s, valid values are: %s", value, enum.GetFullyQualifiedName(), strings.Join(names, ", "))
+}
+
+// Returns the conventional prefix of the values of an enum, its name in upper snake case, like ITEM_STATE_ for ItemState
+func enumValuePrefix(name string) string {
+	var sb strings.Builder
+	for i, c := range name {
+		is_upper := c >= 'A' && c <= 'Z'
+		if i > 0 && is_upper {
+			prev := rune(name[i-1])
+			next_lower := i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z'
+			if prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9' || (prev >= 'A' && prev <= 'Z' && next_lower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(c)
+	}
+	return strings.ToUpper(sb.String()) + "_"
+}
+
 // Sets the value of a field on the message.
-// It supports DynMsgHelperFieldSetter for types that are not scalar.
+// It supports DynMsgHelperFieldSetter for types that are not scalar, and for bytes, where they take precedence
+// over ParseBytesValue.
 func (h *DynMsgHelper) ParseFieldParamValue(fld *desc.FieldDescriptor, value string) (interface{}, error) {
+	if fld.GetType() == descriptor.FieldDescriptorProto_TYPE_BYTES {
+		ok, retval, err := h.parseFieldValue(fld, value)
+		if err != nil || ok {
+			return retval, err
+		}
+	}
+
 	supported, parseval, err := h.ParseScalarFieldValue(fld, value)
 	if err != nil {
 		return nil, err
 	}
 	if !supported {
 		// try the setters
-		for _, parser := range h.opts.fieldValueParsers {
-			ok, retval, err := parser.ParseFieldValue(fld, value)
-			if err != nil {
-				return nil, err
-			}
-			if ok {
-				return retval, nil
-			}
+		ok, retval, err := h.parseFieldValue(fld, value)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return retval, nil
 		}
 
 		return nil, fmt.Errorf("Cannot set value of type %s as string", fld.GetType().String())
@@ -328,6 +393,20 @@ func (h *DynMsgHelper) ParseFieldParamValue(fld *desc.FieldDescriptor, value str
 	return parseval, nil
 }
 
+// Parses the value of a field using a DynMsgHelperFieldValueParser
+func (h *DynMsgHelper) parseFieldValue(fld *desc.FieldDescriptor, value string) (ok bool, retval interface{}, err error) {
+	for _, parser := range h.opts.fieldValueParsers {
+		ok, retval, err = parser.ParseFieldValue(fld, value)
+		if err != nil {
+			return false, nil, err
+		}
+		if ok {
+			return true, retval, nil
+		}
+	}
+	return false, nil, nil
+}
+
 // Gets the value of a field using a DynMsgHelperFieldValueGetter
 func (h *DynMsgHelper) GetFieldValue(msg *dynamic.Message, fld *desc.FieldDescriptor) (ok bool, value string, err error) {
 	for _, fg := range h.opts.fieldValueGetters {
@@ -352,10 +431,16 @@ type DynMsgHelperFieldValueGetter interface {
 	GetFieldValue(msg *dynamic.Message, fld *desc.FieldDescriptor) (ok bool, value string, err error)
 }
 
+// Resolver of the message types of google.protobuf.Any values
+type DynMsgHelperAnyResolver interface {
+	ResolveAnyType(typeURL string) (*desc.MessageDescriptor, error)
+}
+
 // DMH options
 type dmhOptions struct {
 	fieldValueParsers []DynMsgHelperFieldValueParser
 	fieldValueGetters []DynMsgHelperFieldValueGetter
+	anyResolver       DynMsgHelperAnyResolver
 }
 
 func WithDMHFieldValueParsers(setters ...DynMsgHelperFieldValueParser) DMHOption {
@@ -369,3 +454,9 @@ func WithDMHFieldValueGetters(getters ...DynMsgHelperFieldValueGetter) DMHOption
 		o.fieldValueGetters = append(o.fieldValueGetters, getters...)
 	}
 }
+
+func WithDMHAnyResolver(resolver DynMsgHelperAnyResolver) DMHOption {
+	return func(o *dmhOptions) {
+		o.anyResolver = resolver
+	}
+}
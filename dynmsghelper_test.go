@@ -0,0 +1,139 @@
+package grpcget
+
+import (
+	"testing"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+func TestEnumValuePrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Status", want: "STATUS_"},
+		{name: "ItemState", want: "ITEM_STATE_"},
+		{name: "HTTPCode", want: "HTTP_CODE_"},
+		{name: "ResponseHTTPCode", want: "RESPONSE_HTTP_CODE_"},
+		{name: "HTTP", want: "HTTP_"},
+		{name: "Type2Value", want: "TYPE2_VALUE_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enumValuePrefix(tt.name); got != tt.want {
+				t.Errorf("Prefix is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnumValue(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	tests := []struct {
+		enum    string
+		value   string
+		want    int32
+		wantErr string
+	}{
+		{enum: "Status", value: "STATUS_ACTIVE", want: 1},
+		{enum: "Status", value: "status_active", want: 1},
+		{enum: "Status", value: "ACTIVE", want: 1},
+		{enum: "Status", value: "Not_Active", want: 2},
+		{enum: "Status", value: "2", want: 2},
+		{enum: "Status", value: "-1", want: -1},
+		{enum: "HTTPCode", value: "HTTP_CODE_OK", want: 200},
+		{enum: "HTTPCode", value: "ok", want: 200},
+		{enum: "HTTPCode", value: "NOT_FOUND", want: 404},
+		{enum: "HTTPCode", value: "404", want: 404},
+		{enum: "Status", value: "INVALID",
+			wantErr: "Invalid value 'INVALID' for enum test.Status, valid values are: STATUS_UNKNOWN, STATUS_ACTIVE, STATUS_NOT_ACTIVE"},
+		{enum: "HTTPCode", value: "CODE_OK",
+			wantErr: "Invalid value 'CODE_OK' for enum test.HTTPCode, valid values are: HTTP_CODE_UNKNOWN, HTTP_CODE_OK, HTTP_CODE_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.enum+" "+tt.value, func(t *testing.T) {
+			enum := fd.FindEnum("test." + tt.enum)
+			if enum == nil {
+				t.Fatalf("Enum %s not found in test proto", tt.enum)
+			}
+
+			got, err := ParseEnumValue(enum, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Error is %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error parsing value: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Value is %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetParamValue(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	tests := []struct {
+		name    string
+		params  []string
+		want    string
+		wantErr string
+	}{
+		{name: "repeated index", params: []string{"tags.0=a", "tags.1=b"}, want: `tags:"a" tags:"b"`},
+		{name: "repeated overwrite", params: []string{"tags.0=a", "tags.1=b", "tags.0=c"}, want: `tags:"c" tags:"b"`},
+		{name: "repeated overwrite last", params: []string{"tags.0=a", "tags.1=b", "tags.1=c"}, want: `tags:"a" tags:"c"`},
+		{name: "repeated first index", params: []string{"tags.1=a"}, wantErr: "The first repeated field key must be 0"},
+		{name: "repeated invalid index", params: []string{"tags.x=a"}, wantErr: "Repeated key must be an integer"},
+		{name: "repeated message", params: []string{"inners.0.name=a", "inners.1.name=b", "inners.0.num=3"},
+			want: `inners:<name:"a" num:3> inners:<name:"b">`},
+		{name: "map key", params: []string{"labels.env=prod", "labels.team=x"},
+			want: `labels:<key:"env" value:"prod"> labels:<key:"team" value:"x">`},
+		{name: "map overwrite", params: []string{"labels.env=prod", "labels.env=dev"}, want: `labels:<key:"env" value:"dev">`},
+		{name: "map message", params: []string{"named.k.name=a", "named.k.num=2"}, want: `named:<key:"k" value:<name:"a" num:2>>`},
+		{name: "enum name", params: []string{"code=OK"}, want: `code:HTTP_CODE_OK`},
+		{name: "enum number", params: []string{"code=404"}, want: `code:HTTP_CODE_NOT_FOUND`},
+		{name: "enum invalid", params: []string{"code=BAD"},
+			wantErr: "Invalid value 'BAD' for enum test.HTTPCode, valid values are: HTTP_CODE_UNKNOWN, HTTP_CODE_OK, HTTP_CODE_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage(t, fd, "Params")
+			err := setTestParams(msg, tt.params)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Error is %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error setting params: %v", err)
+			}
+			if got := msg.String(); got != tt.want {
+				t.Errorf("Message is %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func setTestParams(msg *dynamic.Message, params []string) error {
+	dmh := NewDynMsgHelper()
+	for _, param := range params {
+		name, value, err := ParseArgumentParam(param)
+		if err != nil {
+			return err
+		}
+		err = dmh.SetParamValue(msg, name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,15 +6,17 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
-	"github.com/jhump/protoreflect/grpcreflect"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 )
 
 // Get options for GrpcGet
@@ -60,14 +62,44 @@ func (g *GrpcGet) checkConnection(ctx context.Context) (*grpc.ClientConn, error)
 	return g.opts.connectionSupplier.GetConnection(ctx)
 }
 
-// creates a *grpcreflect.Client and a *grpc.ClientConn
-func (g *GrpcGet) checkRefClient(ctx context.Context) (*grpcreflect.Client, *grpc.ClientConn, error) {
-	conn, err := g.checkConnection(ctx)
+// creates the descriptor source using the DescriptorSourceSupplier, or the server reflection if not set.
+// Also returns a function that creates the connection on demand, shared with the descriptor source.
+// The returned close function must be called to release the resources.
+func (g *GrpcGet) checkDescriptorSource(ctx context.Context) (DescriptorSource, func() (*grpc.ClientConn, error), func(), error) {
+	var conn *grpc.ClientConn
+	getConn := func() (*grpc.ClientConn, error) {
+		if conn == nil {
+			var err error
+			conn, err = g.checkConnection(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+
+	supplier := g.opts.descriptorSourceSupplier
+	if supplier == nil {
+		supplier = NewReflectionDescriptorSourceSupplier()
+	}
+
+	source, err := supplier.GetDescriptorSource(ctx, getConn)
+
+	closer := func() {
+		if c, ok := source.(io.Closer); ok {
+			c.Close()
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	}
+
 	if err != nil {
-		return nil, nil, err
+		closer()
+		return nil, nil, nil, err
 	}
 
-	return grpcreflect.NewClient(ctx, grpc_reflection_v1alpha.NewServerReflectionClient(conn)), conn, nil
+	return source, getConn, closer, nil
 }
 
 // List services and call ServiceListOutput.OutputServiceList
@@ -76,14 +108,13 @@ func (g *GrpcGet) ListServices(ctx context.Context) error {
 		return errors.New("Must configure OutputServiceList to run this method")
 	}
 
-	refClient, conn, err := g.checkRefClient(ctx)
+	source, _, closer, err := g.checkDescriptorSource(ctx)
 	if err != nil {
 		return err
 	}
-	defer refClient.Reset()
-	defer conn.Close()
+	defer closer()
 
-	services, err := refClient.ListServices()
+	services, err := source.ListServices()
 	if err != nil {
 		return err
 	}
@@ -98,22 +129,26 @@ func (g *GrpcGet) ListServices(ctx context.Context) error {
 
 // List a single service and call ServiceOutput.OutputService
 func (g *GrpcGet) ListService(ctx context.Context, service string) error {
-	if g.opts.outputServiceList == nil {
+	if g.opts.outputService == nil {
 		return errors.New("Must configure OutputService to run this method")
 	}
 
-	refClient, conn, err := g.checkRefClient(ctx)
+	source, _, closer, err := g.checkDescriptorSource(ctx)
 	if err != nil {
 		return err
 	}
-	defer refClient.Reset()
-	defer conn.Close()
+	defer closer()
 
-	svc, err := refClient.ResolveService(service)
+	d, err := source.FindSymbol(service)
 	if err != nil {
 		return err
 	}
 
+	svc, ok := d.(*desc.ServiceDescriptor)
+	if !ok {
+		return fmt.Errorf("Symbol %s is not a service", service)
+	}
+
 	err = g.opts.outputService.OutputService(svc)
 	if err != nil {
 		return err
@@ -122,37 +157,86 @@ func (g *GrpcGet) ListService(ctx context.Context, service string) error {
 	return nil
 }
 
-// Get a symbol and call DescribeOutput.OutputDescribe
+// Get a symbol, or a file if the name ends with ".proto", and call DescribeOutput.OutputDescribe
 func (g *GrpcGet) Describe(ctx context.Context, symbol string) error {
 	if g.opts.outputDescribe == nil {
 		return errors.New("Must configure OutputDescribe to run this method")
 	}
 
-	refClient, conn, err := g.checkRefClient(ctx)
+	source, _, closer, err := g.checkDescriptorSource(ctx)
 	if err != nil {
 		return err
 	}
-	defer refClient.Reset()
-	defer conn.Close()
+	defer closer()
 
-	file, err := refClient.FileContainingSymbol(symbol)
+	var d desc.Descriptor
+	if strings.HasSuffix(symbol, ".proto") {
+		d, err = source.FindFile(symbol)
+	} else {
+		d, err = source.FindSymbol(symbol)
+	}
 	if err != nil {
 		return err
 	}
 
-	d := file.FindSymbol(symbol)
-	if d == nil {
-		return fmt.Errorf("Symbol %s not found", symbol)
-	}
-
 	err = g.opts.outputDescribe.OutputDescribe(d)
-	if err == nil {
+	if err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// Export the descriptors of all services and their dependencies as a binary encoded FileDescriptorSet (a protoset)
+func (g *GrpcGet) ExportDescriptors(ctx context.Context, out io.Writer) error {
+	source, _, closer, err := g.checkDescriptorSource(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	services, err := source.ListServices()
+	if err != nil {
+		return err
+	}
+
+	files, err := collectServiceFiles(source, services)
+	if err != nil {
+		return err
+	}
+
+	b, err := proto.Marshal(fileDescriptorSet(files))
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(b)
+	return err
+}
+
+// Export the files of all services and their dependencies as .proto source files into a directory,
+// creating sub-directories for the file paths
+func (g *GrpcGet) ExportProtoFiles(ctx context.Context, rootDir string) error {
+	source, _, closer, err := g.checkDescriptorSource(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	services, err := source.ListServices()
+	if err != nil {
+		return err
+	}
+
+	files, err := collectServiceFiles(source, services)
+	if err != nil {
+		return err
+	}
+
+	p := &protoprint.Printer{}
+	return p.PrintProtosToFileSystem(files, rootDir)
+}
+
 // Invoke option
 type InvokeOption func(*invokeOptions)
 
@@ -162,80 +246,362 @@ func (g *GrpcGet) Invoke(ctx context.Context, method string, opts ...InvokeOptio
 		return errors.New("Must configure OutputInvoke to run this method")
 	}
 
-	refClient, conn, err := g.checkRefClient(ctx)
+	source, getConn, closer, err := g.checkDescriptorSource(ctx)
 	if err != nil {
 		return err
 	}
-	defer refClient.Reset()
-	defer conn.Close()
+	defer closer()
 
-	file, err := refClient.FileContainingSymbol(method)
+	d, err := source.FindSymbol(method)
 	if err != nil {
 		return err
 	}
 
-	d := file.FindSymbol(method)
-	if d == nil {
-		return fmt.Errorf("Method %s not found", method)
-	}
-
 	md, ok := d.(*desc.MethodDescriptor)
 	if !ok {
 		return fmt.Errorf("Symbol %s is not a method", method)
 	}
 
+	conn, err := getConn()
+	if err != nil {
+		return err
+	}
+
 	var iopts invokeOptions
 	for _, o := range opts {
 		o(&iopts)
 	}
 
+	// create dyn msg helper, resolving Any types with the descriptor source
+	dmh := NewDynMsgHelper(append([]DMHOption{WithDMHAnyResolver(&descriptorSourceAnyResolver{source})}, g.opts.dmhOpts...)...)
+
+	// supplier of the request messages, defaults to a single message using the param setters
+	reqSupplier := iopts.requestSupplier
+	if reqSupplier == nil {
+		reqSupplier = NewSetterInvokeRequestSupplier(iopts.paramSetters)
+	}
+
+	// create grpc stub
+	stub := grpcdynamic.NewStub(conn)
+
+	err = g.invokeMethod(ctx, dmh, stub, md, reqSupplier)
+	if err != nil && g.opts.outputInvokeError != nil && ctx.Err() != context.Canceled {
+		if st, ok := status.FromError(err); ok {
+			outerr := g.opts.outputInvokeError.OutputInvokeError(dmh, st, DecodeStatusDetails(source, st))
+			if outerr != nil {
+				return outerr
+			}
+		}
+	}
+	return err
+}
+
+// Invoke the method according to its streaming type
+func (g *GrpcGet) invokeMethod(ctx context.Context, dmh *DynMsgHelper, stub grpcdynamic.Stub, md *desc.MethodDescriptor, reqSupplier InvokeRequestSupplier) error {
+	if md.IsClientStreaming() {
+		if md.IsServerStreaming() {
+			return g.invokeBidiStream(ctx, dmh, stub, md, reqSupplier)
+		}
+		return g.invokeClientStream(ctx, dmh, stub, md, reqSupplier)
+	}
+
 	// create dynamic message
 	req := dynamic.NewMessage(md.GetInputType())
 
-	// create dyn msg helper
-	dmh := NewDynMsgHelper(g.opts.dmhOpts...)
-
 	// set input parameters
-	for _, setter := range iopts.paramSetters {
-		err = setter.SetInvokeParam(dmh, req)
-		if err != nil {
-			return err
-		}
+	err := reqSupplier.NextInvokeRequest(dmh, req)
+	if err == io.EOF {
+		return errors.New("No request message was supplied")
+	}
+	if err != nil {
+		return err
 	}
 
-	// create grpc stub
-	stub := grpcdynamic.NewStub(conn)
+	if md.IsServerStreaming() {
+		return g.invokeServerStream(ctx, dmh, stub, md, req)
+	}
 
 	var respHeaders metadata.MD
 	var respTrailers metadata.MD
 
 	// invoke
-	ctx, cancel := context.WithCancel(ctx)
-	resp, err := func() (proto.Message, error) {
+	resp, invokeErr := func() (proto.Message, error) {
+		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 		return stub.InvokeRpc(ctx, md, req, grpc.Trailer(&respTrailers), grpc.Header(&respHeaders))
 	}()
+
+	err = g.outputHeaders(ctx, dmh, md, respHeaders)
 	if err != nil {
 		return err
 	}
 
 	// output
-	err = g.opts.outputInvoke.OutputInvoke(dmh, resp)
+	if invokeErr == nil {
+		err = g.opts.outputInvoke.OutputInvoke(dmh, resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.outputTrailers(ctx, dmh, invokeErr, respTrailers)
+}
+
+// Output the request metadata and response headers, if an InvokeMetadataOutput was configured
+func (g *GrpcGet) outputHeaders(ctx context.Context, dmh *DynMsgHelper, md *desc.MethodDescriptor, headers metadata.MD) error {
+	if g.opts.outputInvokeMetadata == nil {
+		return nil
+	}
+	requestMetadata, _ := metadata.FromOutgoingContext(ctx)
+	return g.opts.outputInvokeMetadata.OutputInvokeHeaders(dmh, md, requestMetadata, headers)
+}
+
+// Output the final status and trailers, if an InvokeMetadataOutput was configured, and return the call error
+func (g *GrpcGet) outputTrailers(ctx context.Context, dmh *DynMsgHelper, callErr error, trailers metadata.MD) error {
+	if callErr != nil && ctx.Err() != nil {
+		// cancelled
+		return ctx.Err()
+	}
+	if g.opts.outputInvokeMetadata != nil {
+		err := g.opts.outputInvokeMetadata.OutputInvokeTrailers(dmh, status.Convert(callErr), trailers)
+		if err != nil {
+			return err
+		}
+	}
+	return callErr
+}
+
+// Invoke a server streaming method, calling InvokeStreamOutput.OutputInvokeStreamMessage for each message as
+// it arrives, until the end of the stream or the context is cancelled
+func (g *GrpcGet) invokeServerStream(ctx context.Context, dmh *DynMsgHelper, stub grpcdynamic.Stub, md *desc.MethodDescriptor, req proto.Message) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := stub.InvokeRpcServerStream(ctx, md, req)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if g.opts.outputInvokeMetadata != nil {
+		// errors are returned when receiving
+		headers, _ := stream.Header()
+		err = g.outputHeaders(ctx, dmh, md, headers)
+		if err != nil {
+			return err
+		}
+	}
+
+	output := g.streamOutput()
+
+	err = output.OutputInvokeStreamBegin(dmh, md)
+	if err != nil {
+		return err
+	}
+
+	recvErr, err := g.receiveStream(dmh, output, stream.RecvMsg)
+	if err != nil {
+		return err
+	}
+
+	return g.endStream(ctx, dmh, output, recvErr, stream.Trailer())
+}
+
+// Invoke a bidirectional streaming method. Messages from the InvokeRequestSupplier are sent concurrently
+// with the output of the received messages, and the send side is closed when the supplier returns io.EOF,
+// while the responses continue to be received until the end of the stream.
+func (g *GrpcGet) invokeBidiStream(ctx context.Context, dmh *DynMsgHelper, stub grpcdynamic.Stub, md *desc.MethodDescriptor, reqSupplier InvokeRequestSupplier) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := stub.InvokeRpcBidiStream(ctx, md)
+	if err != nil {
+		return err
+	}
+
+	output := g.streamOutput()
+
+	err = output.OutputInvokeStreamBegin(dmh, md)
+	if err != nil {
+		return err
+	}
+
+	// send requests
+	sendErr := make(chan error, 1)
+	go func() {
+		err := func() error {
+			for {
+				req := dynamic.NewMessage(md.GetInputType())
+				err := reqSupplier.NextInvokeRequest(dmh, req)
+				if err == io.EOF {
+					// half-close, responses are still received
+					return stream.CloseSend()
+				}
+				if err != nil {
+					return err
+				}
+
+				err = stream.SendMsg(req)
+				if err == io.EOF {
+					// the server ended the call, the error will be returned by RecvMsg
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}()
+		if err != nil {
+			// stop receiving
+			sendErr <- err
+			cancel()
+		}
+	}()
+
+	if g.opts.outputInvokeMetadata != nil {
+		// errors are returned when receiving
+		headers, _ := stream.Header()
+		err = g.outputHeaders(ctx, dmh, md, headers)
+		if err != nil {
+			return err
+		}
+	}
+
+	recvErr, err := g.receiveStream(dmh, output, stream.RecvMsg)
+	if err != nil {
+		return err
+	}
+
+	err = g.endStream(ctx, dmh, output, recvErr, stream.Trailer())
+
+	// the sender may still be waiting for requests, only check if it failed
+	select {
+	case sendErr := <-sendErr:
+		return sendErr
+	default:
+	}
+
+	return err
+}
+
+// Returns the output for streaming responses. If an InvokeStreamOutput was not configured, uses the InvokeOutput
+// if it implements InvokeStreamOutput, or adapts it to be called once per message.
+func (g *GrpcGet) streamOutput() InvokeStreamOutput {
+	if g.opts.outputInvokeStream != nil {
+		return g.opts.outputInvokeStream
+	}
+	if output, ok := g.opts.outputInvoke.(InvokeStreamOutput); ok {
+		return output
+	}
+	return NewInvokeStreamOutputAdapter(g.opts.outputInvoke)
+}
+
+// Receive all messages of a stream calling InvokeStreamOutput.OutputInvokeStreamMessage for each one.
+// Returns the error that ended the stream, if it wasn't io.EOF, in recvErr.
+func (g *GrpcGet) receiveStream(dmh *DynMsgHelper, output InvokeStreamOutput, recv func() (proto.Message, error)) (recvErr error, err error) {
+	for {
+		resp, err := recv()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return err, nil
+		}
+
+		err = output.OutputInvokeStreamMessage(dmh, resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Output the final status and trailers of a stream, and return the status error. The end of the stream is
+// output even when the call was cancelled, so outputs like XML are complete.
+func (g *GrpcGet) endStream(ctx context.Context, dmh *DynMsgHelper, output InvokeStreamOutput, recvErr error, trailers metadata.MD) error {
+	st, streamTrailers := status.Convert(recvErr), trailers
+	if st == nil {
+		st = status.New(codes.OK, "")
+	}
+	// the metadata output outputs the status and trailers itself
+	if g.opts.outputInvokeMetadata != nil {
+		st, streamTrailers = nil, nil
+	}
+
+	err := output.OutputInvokeStreamEnd(dmh, st, streamTrailers)
+	if err != nil {
+		return err
+	}
+
+	return g.outputTrailers(ctx, dmh, recvErr, trailers)
+}
+
+// Invoke a client streaming method, sending all messages from the InvokeRequestSupplier and calling
+// InvokeOutput.OutputInvoke with the single response
+func (g *GrpcGet) invokeClientStream(ctx context.Context, dmh *DynMsgHelper, stub grpcdynamic.Stub, md *desc.MethodDescriptor, reqSupplier InvokeRequestSupplier) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := stub.InvokeRpcClientStream(ctx, md)
+	if err != nil {
+		return err
+	}
+
+	for {
+		req := dynamic.NewMessage(md.GetInputType())
+		err = reqSupplier.NextInvokeRequest(dmh, req)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		err = stream.SendMsg(req)
+		if err == io.EOF {
+			// the server ended the call, the error will be returned by CloseAndReceive
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	resp, recvErr := stream.CloseAndReceive()
+	if recvErr != nil && ctx.Err() != nil {
+		// cancelled
+		return ctx.Err()
+	}
+
+	if g.opts.outputInvokeMetadata != nil {
+		headers, _ := stream.Header()
+		err = g.outputHeaders(ctx, dmh, md, headers)
+		if err != nil {
+			return err
+		}
+	}
+
+	if recvErr == nil {
+		err = g.opts.outputInvoke.OutputInvoke(dmh, resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.outputTrailers(ctx, dmh, recvErr, stream.Trailer())
 }
 
 // Get options
 type getOptions struct {
 	connectionSupplier ConnectionSupplier
 
-	outputServiceList ServiceListOutput
-	outputService     ServiceOutput
-	outputDescribe    DescribeOutput
-	outputInvoke      InvokeOutput
+	outputServiceList    ServiceListOutput
+	outputService        ServiceOutput
+	outputDescribe       DescribeOutput
+	outputInvoke         InvokeOutput
+	outputInvokeStream   InvokeStreamOutput
+	outputInvokeMetadata InvokeMetadataOutput
+	outputInvokeError    InvokeErrorOutput
+
+	descriptorSourceSupplier DescriptorSourceSupplier
 
 	dmhOpts []DMHOption
 }
@@ -267,6 +633,29 @@ func WithConnection(conn *grpc.ClientConn) GetOption {
 	}
 }
 
+// Sets the source of descriptors, the default is the server reflection
+func WithDescriptorSource(supplier DescriptorSourceSupplier) GetOption {
+	return func(o *getOptions) {
+		o.descriptorSourceSupplier = supplier
+	}
+}
+
+// Use the server reflection as the source of descriptors, trying the reflection service versions in the passed order
+func WithReflectionVersionOrder(order ReflectionVersionOrder) GetOption {
+	return WithDescriptorSource(&ReflectionDescriptorSourceSupplier{VersionOrder: order})
+}
+
+// Use descriptors parsed from local .proto source files instead of the server reflection.
+// The file names are relative to the import paths, if any.
+func WithProtoFiles(importPaths []string, fileNames ...string) GetOption {
+	return WithDescriptorSource(NewProtoFileDescriptorSourceSupplier(importPaths, fileNames...))
+}
+
+// Use descriptors from protoset files instead of the server reflection.
+func WithProtosetFiles(fileNames ...string) GetOption {
+	return WithDescriptorSource(NewProtosetDescriptorSourceSupplier(fileNames...))
+}
+
 func WithOutputServiceList(output ServiceListOutput) GetOption {
 	return func(o *getOptions) {
 		o.outputServiceList = output
@@ -291,6 +680,33 @@ func WithOutputInvoke(output InvokeOutput) GetOption {
 	}
 }
 
+func WithOutputInvokeStream(output InvokeStreamOutput) GetOption {
+	return func(o *getOptions) {
+		o.outputInvokeStream = output
+	}
+}
+
+func WithOutputInvokeMetadata(output InvokeMetadataOutput) GetOption {
+	return func(o *getOptions) {
+		o.outputInvokeMetadata = output
+	}
+}
+
+func WithOutputInvokeError(output InvokeErrorOutput) GetOption {
+	return func(o *getOptions) {
+		o.outputInvokeError = output
+	}
+}
+
+// Sets the invoke error output only if none was set
+func WithOutputInvokeErrorIfUnset(output InvokeErrorOutput) GetOption {
+	return func(o *getOptions) {
+		if o.outputInvokeError == nil {
+			o.outputInvokeError = output
+		}
+	}
+}
+
 func WithDMHOpts(opts ...DMHOption) GetOption {
 	return func(o *getOptions) {
 		o.dmhOpts = append(o.dmhOpts, opts...)
@@ -299,5 +715,6 @@ func WithDMHOpts(opts ...DMHOption) GetOption {
 
 // Invoke options
 type invokeOptions struct {
-	paramSetters []InvokeParamSetter
+	paramSetters    []InvokeParamSetter
+	requestSupplier InvokeRequestSupplier
 }
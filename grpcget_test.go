@@ -0,0 +1,357 @@
+package grpcget
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Test service. The requests control the responses:
+//   - UnaryCall responds with the payload body, or fails with the response status
+//   - StreamingOutputCall sends a message for each response parameter with a body of its size, then fails with
+//     the response status if set. A negative size blocks until the call is cancelled.
+//   - StreamingInputCall responds with the total size of the bodies, or fails if a body is "fail"
+//   - FullDuplexCall echoes each request, and after the client half-closes sends the number of requests
+type testServer struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+func (s *testServer) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	grpc.SetHeader(ctx, metadata.Pairs("x-header", "h"))
+	grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "t"))
+	if code := req.GetResponseStatus().GetCode(); code != 0 {
+		return nil, status.Error(codes.Code(code), req.GetResponseStatus().GetMessage())
+	}
+	return &testpb.SimpleResponse{Username: string(req.GetPayload().GetBody())}, nil
+}
+
+func (s *testServer) StreamingOutputCall(req *testpb.StreamingOutputCallRequest, stream testpb.TestService_StreamingOutputCallServer) error {
+	stream.SetTrailer(metadata.Pairs("x-trailer", "t"))
+	for _, p := range req.GetResponseParameters() {
+		if p.GetSize() < 0 {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}
+		err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: &testpb.Payload{Body: bytes.Repeat([]byte("x"), int(p.GetSize()))}})
+		if err != nil {
+			return err
+		}
+	}
+	if code := req.GetResponseStatus().GetCode(); code != 0 {
+		return status.Error(codes.Code(code), req.GetResponseStatus().GetMessage())
+	}
+	return nil
+}
+
+func (s *testServer) StreamingInputCall(stream testpb.TestService_StreamingInputCallServer) error {
+	total := 0
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&testpb.StreamingInputCallResponse{AggregatedPayloadSize: int32(total)})
+		}
+		if err != nil {
+			return err
+		}
+		if string(req.GetPayload().GetBody()) == "fail" {
+			return status.Error(codes.InvalidArgument, "invalid payload")
+		}
+		total += len(req.GetPayload().GetBody())
+	}
+}
+
+func (s *testServer) FullDuplexCall(stream testpb.TestService_FullDuplexCallServer) error {
+	count := 0
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			// only sent after the client half-closes
+			return stream.Send(&testpb.StreamingOutputCallResponse{Payload: &testpb.Payload{Body: []byte(fmt.Sprintf("end %d", count))}})
+		}
+		if err != nil {
+			return err
+		}
+		if code := req.GetResponseStatus().GetCode(); code != 0 {
+			return status.Error(codes.Code(code), req.GetResponseStatus().GetMessage())
+		}
+		count++
+		err = stream.Send(&testpb.StreamingOutputCallResponse{Payload: req.GetPayload()})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Starts the test server in memory, returning a function that connects to it
+func testStartServer(t *testing.T) func() *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	testpb.RegisterTestServiceServer(s, &testServer{})
+	reflection.Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return func() *grpc.ClientConn {
+		conn, err := grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("Error connecting to test server: %v", err)
+		}
+		return conn
+	}
+}
+
+// Creates a GrpcGet connected to the test server, with the default output writing bytes as text
+func testGrpcGet(connect func() *grpc.ClientConn, out io.Writer, opts ...GetOption) *GrpcGet {
+	output := NewDefaultInvokeOutput(out)
+	output.BytesEncoding = BytesText
+	return NewGrpcGet(append([]GetOption{WithConnection(connect()), WithOutputInvoke(output)}, opts...)...)
+}
+
+// InvokeOutput that is not an InvokeStreamOutput, to test the adapter
+type testRecordOutput struct {
+	values []string
+}
+
+func (o *testRecordOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	b, err := value.(*dynamic.Message).MarshalJSON()
+	if err != nil {
+		return err
+	}
+	o.values = append(o.values, string(b))
+	return nil
+}
+
+// InvokeStreamOutput that cancels the call after the first message
+type testCancelOutput struct {
+	InvokeStreamOutput
+	cancel context.CancelFunc
+}
+
+func (o *testCancelOutput) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	err := o.InvokeStreamOutput.OutputInvokeStreamMessage(dmh, value)
+	o.cancel()
+	return err
+}
+
+func TestInvoke(t *testing.T) {
+	connect := testStartServer(t)
+
+	const (
+		unary        = "grpc.testing.TestService.UnaryCall"
+		serverStream = "grpc.testing.TestService.StreamingOutputCall"
+		clientStream = "grpc.testing.TestService.StreamingInputCall"
+		bidiStream   = "grpc.testing.TestService.FullDuplexCall"
+	)
+
+	tests := []struct {
+		name     string
+		method   string
+		opts     []InvokeOption
+		metadata bool
+		want     string
+		wantErr  string
+		// the output is not checked, the received messages depend on when the send fails
+		anyOutput bool
+	}{
+		{name: "unary", method: unary, opts: []InvokeOption{WithInvokeParams("payload.body=hello")},
+			want: "username: hello\noauth_scope: \nserver_id: \ngrpclb_route_type: GRPCLB_ROUTE_TYPE_UNKNOWN (0)\nhostname: \n"},
+		{name: "unary error", method: unary, opts: []InvokeOption{WithInvokeParams("response_status.code=3", "response_status.message=bad")},
+			want: "", wantErr: "rpc error: code = InvalidArgument desc = bad"},
+		{name: "unary no request", method: unary, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader(""))},
+			wantErr: "No request message was supplied"},
+		{name: "unary metadata", method: unary, metadata: true, opts: []InvokeOption{WithInvokeParams("payload.body=hello")},
+			want: "Method: grpc.testing.TestService.UnaryCall\n\nRequest metadata:\n(empty)\n\n" +
+				"Response headers:\ncontent-type: application/grpc\nx-header: h\n\n" +
+				"Response:\nusername: hello\noauth_scope: \nserver_id: \ngrpclb_route_type: GRPCLB_ROUTE_TYPE_UNKNOWN (0)\nhostname: \n\n" +
+				"Response trailers:\nx-trailer: t\n\nStatus: OK\n"},
+		{name: "server stream", method: serverStream, opts: []InvokeOption{WithInvokeParams("response_parameters.0.size=1", "response_parameters.1.size=2")},
+			want: "payload:\n\ttype: COMPRESSABLE (0)\n\tbody: x\n---\npayload:\n\ttype: COMPRESSABLE (0)\n\tbody: xx\n---\nstatus: OK\ntrailer x-trailer: t\n"},
+		{name: "server stream empty", method: serverStream,
+			// without messages the trailers are sent with the headers
+			want: "---\nstatus: OK\ntrailer content-type: application/grpc\ntrailer x-trailer: t\n"},
+		{name: "server stream error after messages", method: serverStream,
+			opts:    []InvokeOption{WithInvokeParams("response_parameters.0.size=1", "response_status.code=9", "response_status.message=stopped")},
+			want:    "payload:\n\ttype: COMPRESSABLE (0)\n\tbody: x\n---\nstatus: FailedPrecondition: stopped\ntrailer x-trailer: t\n",
+			wantErr: "rpc error: code = FailedPrecondition desc = stopped"},
+		{name: "server stream metadata", method: serverStream, metadata: true, opts: []InvokeOption{WithInvokeParams("response_parameters.0.size=1")},
+			want: "Method: grpc.testing.TestService.StreamingOutputCall\n\nRequest metadata:\n(empty)\n\n" +
+				"Response headers:\ncontent-type: application/grpc\n\n" +
+				"Response:\npayload:\n\ttype: COMPRESSABLE (0)\n\tbody: x\n---\n\n" +
+				"Response trailers:\nx-trailer: t\n\nStatus: OK\n"},
+		{name: "client stream", method: clientStream, opts: []InvokeOption{WithInvokeParamGroups("+", "payload.body=ab", "+", "payload.body=cde")},
+			want: "aggregated_payload_size: 5\n"},
+		{name: "client stream reader", method: clientStream, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader("{\"payload\":{\"body\":\"YWI=\"}}\n\npayload.body=xyz\n"))},
+			want: "aggregated_payload_size: 5\n"},
+		{name: "client stream no requests", method: clientStream, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader(""))},
+			want: "aggregated_payload_size: 0\n"},
+		{name: "client stream error", method: clientStream, opts: []InvokeOption{WithInvokeParamGroups("+", "payload.body=ab", "+", "payload.body=fail")},
+			want: "", wantErr: "rpc error: code = InvalidArgument desc = invalid payload"},
+		{name: "client stream invalid request", method: clientStream, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader("payload.body=ab\ninvalid\n"))},
+			want: "", wantErr: "Error parsing request on line 2: Invoke param must be in the format name=value"},
+		{name: "bidi stream", method: bidiStream, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader("payload.body=a\npayload.body=b\n"))},
+			want: "payload:\n\ttype: COMPRESSABLE (0)\n\tbody: a\n---\npayload:\n\ttype: COMPRESSABLE (0)\n\tbody: b\n---\n" +
+				"payload:\n\ttype: COMPRESSABLE (0)\n\tbody: end 2\n---\nstatus: OK\n"},
+		{name: "bidi stream no requests", method: bidiStream, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader(""))},
+			want: "payload:\n\ttype: COMPRESSABLE (0)\n\tbody: end 0\n---\nstatus: OK\n"},
+		{name: "bidi stream error after messages", method: bidiStream,
+			opts:    []InvokeOption{WithInvokeRequestReader(strings.NewReader("payload.body=a\nresponse_status.code=5 response_status.message=gone\n"))},
+			want:    "payload:\n\ttype: COMPRESSABLE (0)\n\tbody: a\n---\nstatus: NotFound: gone\n",
+			wantErr: "rpc error: code = NotFound desc = gone"},
+		{name: "bidi stream invalid request", method: bidiStream, opts: []InvokeOption{WithInvokeRequestReader(strings.NewReader("payload.body=a\ninvalid\n"))},
+			wantErr: "Error parsing request on line 2: Invoke param must be in the format name=value", anyOutput: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			var opts []GetOption
+			if tt.metadata {
+				opts = append(opts, WithOutputInvokeMetadata(NewDefaultInvokeMetadataOutput(&b)))
+			}
+
+			err := testGrpcGet(connect, &b, opts...).Invoke(context.Background(), tt.method, tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Error is %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Error invoking: %v", err)
+			}
+			if !tt.anyOutput && b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestInvokeStreamOutputAdapter(t *testing.T) {
+	connect := testStartServer(t)
+
+	output := &testRecordOutput{}
+	gg := NewGrpcGet(WithConnection(connect()), WithOutputInvoke(output))
+	err := gg.Invoke(context.Background(), "grpc.testing.TestService.StreamingOutputCall",
+		WithInvokeParams("response_parameters.0.size=1", "response_parameters.1.size=2"))
+	if err != nil {
+		t.Fatalf("Error invoking: %v", err)
+	}
+
+	// called once per message, without the status
+	want := []string{`{"payload":{"body":"eA=="}}`, `{"payload":{"body":"eHg="}}`}
+	if strings.Join(output.values, "|") != strings.Join(want, "|") {
+		t.Errorf("Output values are %q, want %q", output.values, want)
+	}
+}
+
+func TestInvokeServerStreamCancel(t *testing.T) {
+	connect := testStartServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var b bytes.Buffer
+	output := NewDefaultInvokeOutput(&b)
+	output.BytesEncoding = BytesText
+	gg := NewGrpcGet(WithConnection(connect()), WithOutputInvoke(output),
+		WithOutputInvokeStream(&testCancelOutput{InvokeStreamOutput: output, cancel: cancel}))
+
+	// the second message blocks until cancelled
+	err := gg.Invoke(ctx, "grpc.testing.TestService.StreamingOutputCall",
+		WithInvokeParams("response_parameters.0.size=1", "response_parameters.1.size=-1"))
+	if err != context.Canceled {
+		t.Fatalf("Error is %v, want %v", err, context.Canceled)
+	}
+	// the end of the stream is output with the cancelled status
+	if want := "payload:\n\ttype: COMPRESSABLE (0)\n\tbody: x\n---\nstatus: Canceled: context canceled\n"; b.String() != want {
+		t.Errorf("Output is %q, want %q", b.String(), want)
+	}
+}
+
+func TestInvokeStreamXMLComplete(t *testing.T) {
+	connect := testStartServer(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		opts    []InvokeOption
+		cancel  bool
+		wantErr error
+	}{
+		{name: "server stream cancelled", method: "grpc.testing.TestService.StreamingOutputCall", cancel: true,
+			opts: []InvokeOption{WithInvokeParams("response_parameters.0.size=1", "response_parameters.1.size=-1")}, wantErr: context.Canceled},
+		{name: "bidi stream invalid request", method: "grpc.testing.TestService.FullDuplexCall",
+			opts:    []InvokeOption{WithInvokeRequestReader(strings.NewReader("payload.body=a\ninvalid\n"))},
+			wantErr: errors.New("Error parsing request on line 2: Invoke param must be in the format name=value")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var b bytes.Buffer
+			output := NewXMLInvokeOutput(&b)
+			opts := []GetOption{WithConnection(connect()), WithOutputInvoke(output)}
+			if tt.cancel {
+				opts = append(opts, WithOutputInvokeStream(&testCancelOutput{InvokeStreamOutput: output, cancel: cancel}))
+			}
+
+			err := NewGrpcGet(opts...).Invoke(ctx, tt.method, tt.opts...)
+			if err == nil || err.Error() != tt.wantErr.Error() {
+				t.Fatalf("Error is %v, want %v", err, tt.wantErr)
+			}
+
+			// the stream element is closed
+			out := b.String()
+			d := xml.NewDecoder(strings.NewReader(out))
+			for {
+				_, err = d.Token()
+				if err != nil {
+					break
+				}
+			}
+			if err != io.EOF {
+				t.Errorf("Output is not well-formed XML: %v", err)
+			}
+			if !strings.HasSuffix(strings.TrimSpace(out), "</stream>") {
+				t.Errorf("Output does not end the stream: %q", out)
+			}
+		})
+	}
+}
+
+func TestWithOutputInvokeErrorIfUnset(t *testing.T) {
+	configured := NewJSONInvokeErrorOutput(io.Discard)
+	fallback := NewDefaultInvokeErrorOutput(io.Discard)
+
+	g := NewGrpcGet(WithOutputInvokeError(configured), WithOutputInvokeErrorIfUnset(fallback))
+	if g.opts.outputInvokeError != configured {
+		t.Errorf("configured error output was replaced")
+	}
+	g = NewGrpcGet(WithOutputInvokeErrorIfUnset(fallback))
+	if g.opts.outputInvokeError != fallback {
+		t.Errorf("error output was not set")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Interface to supply a connection to GrpcGet
@@ -14,6 +16,19 @@ type ConnectionSupplier interface {
 	GetConnection(ctx context.Context) (*grpc.ClientConn, error)
 }
 
+// Source of the descriptors of services and symbols
+type DescriptorSource interface {
+	ListServices() ([]string, error)
+	FindSymbol(symbol string) (desc.Descriptor, error)
+	FindFile(fileName string) (*desc.FileDescriptor, error)
+}
+
+// Interface to supply a DescriptorSource to GrpcGet. The getConn function returns the server connection,
+// for sources that need it.
+type DescriptorSourceSupplier interface {
+	GetDescriptorSource(ctx context.Context, getConn func() (*grpc.ClientConn, error)) (DescriptorSource, error)
+}
+
 // Interface that outputs a service list
 type ServiceListOutput interface {
 	OutputServiceList(services []string) error
@@ -34,7 +49,39 @@ type InvokeParamSetter interface {
 	SetInvokeParam(dmh *DynMsgHelper, req *dynamic.Message) error
 }
 
+// Supplier of the request messages of an invoke. Client streaming methods send all supplied messages,
+// other methods use only the first one.
+type InvokeRequestSupplier interface {
+	// Sets the values of the next request message, returning io.EOF when there are no more messages
+	NextInvokeRequest(dmh *DynMsgHelper, req *dynamic.Message) error
+}
+
 // Interface that outputs the response of an invoke
 type InvokeOutput interface {
 	OutputInvoke(dmh *DynMsgHelper, value proto.Message) error
 }
+
+// Interface that outputs the responses of a streaming invoke as they arrive
+type InvokeStreamOutput interface {
+	// Called before the first response message
+	OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error
+	// Called for each response message
+	OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error
+	// Called after the end of the stream with the final status and trailers. Both are nil when an
+	// InvokeMetadataOutput is configured, as it outputs them.
+	OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error
+}
+
+// Interface that outputs the metadata and final status of an invoke
+type InvokeMetadataOutput interface {
+	// Called before the response output, with the request metadata and the response headers
+	OutputInvokeHeaders(dmh *DynMsgHelper, method *desc.MethodDescriptor, requestMetadata metadata.MD, headers metadata.MD) error
+	// Called after the response output, or when the call fails, with the final status and trailers
+	OutputInvokeTrailers(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error
+}
+
+// Interface that outputs the status of a failed invoke
+type InvokeErrorOutput interface {
+	// The details are decoded with DecodeStatusDetails
+	OutputInvokeError(dmh *DynMsgHelper, st *status.Status, details []proto.Message) error
+}
@@ -2,8 +2,13 @@ package grpcget
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 //
@@ -43,3 +48,72 @@ func WithInvokeParams(params ...string) InvokeOption {
 		o.paramSetters = append(o.paramSetters, NewParameterInvokeParamSetter(params...))
 	}
 }
+
+//
+// JSONInvokeParamSetter
+//
+// Params are a proto3 JSON document, merged into the request message, so fields not in the
+// document keep the values set by previous setters. The document is parsed with the protojson package, which
+// supports the JSON mapping of all the well-known types, with Any values resolved with the DynMsgHelper.
+//
+type JSONInvokeParamSetter struct {
+	Data []byte
+}
+
+func NewJSONInvokeParamSetter(data []byte) *JSONInvokeParamSetter {
+	return &JSONInvokeParamSetter{
+		Data: data,
+	}
+}
+
+func (i *JSONInvokeParamSetter) SetInvokeParam(dmh *DynMsgHelper, req *dynamic.Message) error {
+	u := protojson.UnmarshalOptions{
+		Resolver: &jsonTypeResolver{dmh: dmh},
+	}
+
+	msg := dynamicpb.NewMessage(req.GetMessageDescriptor().UnwrapMessage())
+	err := u.Unmarshal(i.Data, msg)
+	if err != nil {
+		return fmt.Errorf("Error parsing JSON request: %v", err)
+	}
+
+	// merged in the binary format, which appends repeated fields and merges message fields
+	b, err := protov2.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return req.UnmarshalMerge(b)
+}
+
+func WithInvokeJSON(data []byte) InvokeOption {
+	return func(o *invokeOptions) {
+		o.paramSetters = append(o.paramSetters, NewJSONInvokeParamSetter(data))
+	}
+}
+
+// Returns the input format of a request file from its extension: "json", "text" for .txt, .textproto,
+// .pbtxt and .prototxt, "yaml" for .yaml and .yml, or "" if unknown
+func InputFormatFromFileName(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".txt", ".textproto", ".pbtxt", ".prototxt":
+		return "text"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
+
+// Returns the InvokeParamSetter of the input format, which can be json, text or yaml
+func NewFormatInvokeParamSetter(format string, data []byte) (InvokeParamSetter, error) {
+	switch format {
+	case "json":
+		return NewJSONInvokeParamSetter(data), nil
+	case "text":
+		return NewTextInvokeParamSetter(data), nil
+	case "yaml":
+		return NewYAMLInvokeParamSetter(data), nil
+	}
+	return nil, fmt.Errorf("Unknown input format: %s", format)
+}
@@ -0,0 +1,70 @@
+package grpcget
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONInvokeParamSetter(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper(WithDMHAnyResolver(&descriptorSourceAnyResolver{NewFileDescriptorSource(fd)}))
+
+	tests := []struct {
+		name    string
+		message string
+		data    []string
+		want    string
+		wantErr string
+	}{
+		{name: "scalars", message: "Record", data: []string{`{"name":"x","status":"STATUS_ACTIVE","data":"aGk="}`},
+			want: `{"name":"x","status":"STATUS_ACTIVE","data":"aGk="}`},
+		{name: "merge", message: "Record", data: []string{`{"name":"x","tags":["a"],"inner":{"name":"i"}}`, `{"tags":["b"],"inner":{"num":2}}`},
+			want: `{"name":"x","inner":{"name":"i","num":2},"tags":["a","b"]}`},
+		{name: "timestamp", message: "W_timestamp", data: []string{`{"v":"2020-01-02T03:04:05Z"}`},
+			want: `{"v":"2020-01-02T03:04:05Z"}`},
+		{name: "struct", message: "W_struct", data: []string{`{"v":{"a":1,"b":[true,null,"x"]}}`},
+			want: `{"v":{"a":1,"b":[true,null,"x"]}}`},
+		{name: "wrapper", message: "W_int64", data: []string{`{"v":"5"}`}, want: `{"v":"5"}`},
+		{name: "fieldmask", message: "W_fieldmask", data: []string{`{"v":"a.b,cD"}`}, want: `{"v":{"paths":["a.b","c_d"]}}`},
+		{name: "any message", message: "W_any", data: []string{`{"v":{"@type":"type.googleapis.com/test.Inner","name":"n"}}`},
+			want: `{"v":{"@type":"type.googleapis.com/test.Inner","name":"n"}}`},
+		{name: "any timestamp", message: "W_any", data: []string{`{"v":{"@type":"type.googleapis.com/google.protobuf.Timestamp","value":"2020-01-01T00:00:00Z"}}`},
+			want: `{"v":{"@type":"type.googleapis.com/google.protobuf.Timestamp","value":"2020-01-01T00:00:00Z"}}`},
+		{name: "any duration", message: "W_any", data: []string{`{"v":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"1.5s"}}`},
+			want: `{"v":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"1.500s"}}`},
+		{name: "any unknown", message: "W_any", data: []string{`{"v":{"@type":"type.googleapis.com/test.Unknown"}}`},
+			wantErr: "Error parsing JSON request"},
+		{name: "unknown field", message: "Record", data: []string{`{"nope":1}`}, wantErr: "Error parsing JSON request"},
+		{name: "invalid value", message: "Record", data: []string{`{"name":1}`}, wantErr: "Error parsing JSON request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage(t, fd, tt.message)
+			var err error
+			for _, data := range tt.data {
+				err = NewJSONInvokeParamSetter([]byte(data)).SetInvokeParam(dmh, msg)
+				if err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("Error is %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error setting params: %v", err)
+			}
+
+			b, err := msg.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Message is %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package grpcget
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+//
+// SetterInvokeRequestSupplier
+//
+// Supplies one request message for each list of InvokeParamSetter.
+//
+type SetterInvokeRequestSupplier struct {
+	Requests [][]InvokeParamSetter
+
+	current int
+}
+
+func NewSetterInvokeRequestSupplier(requests ...[]InvokeParamSetter) *SetterInvokeRequestSupplier {
+	return &SetterInvokeRequestSupplier{
+		Requests: requests,
+	}
+}
+
+// Creates a SetterInvokeRequestSupplier from name=value params, where each group of params separated by
+// the marker is a request message
+func NewParameterGroupInvokeRequestSupplier(marker string, params ...string) *SetterInvokeRequestSupplier {
+	var requests [][]InvokeParamSetter
+	var group []string
+	for _, p := range params {
+		if p == marker {
+			requests = append(requests, []InvokeParamSetter{NewParameterInvokeParamSetter(group...)})
+			group = nil
+		} else {
+			group = append(group, p)
+		}
+	}
+	requests = append(requests, []InvokeParamSetter{NewParameterInvokeParamSetter(group...)})
+
+	return NewSetterInvokeRequestSupplier(requests...)
+}
+
+func (i *SetterInvokeRequestSupplier) NextInvokeRequest(dmh *DynMsgHelper, req *dynamic.Message) error {
+	if i.current >= len(i.Requests) {
+		return io.EOF
+	}
+
+	for _, setter := range i.Requests[i.current] {
+		err := setter.SetInvokeParam(dmh, req)
+		if err != nil {
+			return err
+		}
+	}
+
+	i.current++
+	return nil
+}
+
+//
+// ReaderInvokeRequestSupplier
+//
+// Supplies one request message for each non-empty line of the reader.
+// Lines starting with "{" are parsed as JSON, other lines as name=value params separated by spaces,
+// where values can be quoted.
+//
+type ReaderInvokeRequestSupplier struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewReaderInvokeRequestSupplier(r io.Reader) *ReaderInvokeRequestSupplier {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	return &ReaderInvokeRequestSupplier{
+		scanner: scanner,
+	}
+}
+
+func (i *ReaderInvokeRequestSupplier) NextInvokeRequest(dmh *DynMsgHelper, req *dynamic.Message) error {
+	for i.scanner.Scan() {
+		i.line++
+
+		line := strings.TrimSpace(i.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "{") {
+			err := NewJSONInvokeParamSetter([]byte(line)).SetInvokeParam(dmh, req)
+			if err != nil {
+				return fmt.Errorf("Error parsing request on line %d: %v", i.line, err)
+			}
+			return nil
+		}
+
+		params, err := SplitArguments(line)
+		if err != nil {
+			return fmt.Errorf("Error parsing request on line %d: %v", i.line, err)
+		}
+
+		err = NewParameterInvokeParamSetter(params...).SetInvokeParam(dmh, req)
+		if err != nil {
+			return fmt.Errorf("Error parsing request on line %d: %v", i.line, err)
+		}
+		return nil
+	}
+
+	if err := i.scanner.Err(); err != nil {
+		return err
+	}
+
+	return io.EOF
+}
+
+func WithInvokeRequestSupplier(supplier InvokeRequestSupplier) InvokeOption {
+	return func(o *invokeOptions) {
+		o.requestSupplier = supplier
+	}
+}
+
+func WithInvokeParamGroups(marker string, params ...string) InvokeOption {
+	return func(o *invokeOptions) {
+		o.requestSupplier = NewParameterGroupInvokeRequestSupplier(marker, params...)
+	}
+}
+
+func WithInvokeRequestReader(r io.Reader) InvokeOption {
+	return func(o *invokeOptions) {
+		o.requestSupplier = NewReaderInvokeRequestSupplier(r)
+	}
+}
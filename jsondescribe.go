@@ -0,0 +1,283 @@
+package grpcget
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+//
+// Descriptions
+//
+// Structured descriptions of the descriptors, used by the JSON outputs.
+//
+
+type FileDescription struct {
+	Name     string                `json:"name"`
+	Package  string                `json:"package"`
+	Services []*ServiceDescription `json:"services,omitempty"`
+	Messages []*MessageDescription `json:"messages,omitempty"`
+	Enums    []*EnumDescription    `json:"enums,omitempty"`
+}
+
+type ServiceDescription struct {
+	Name     string               `json:"name"`
+	FullName string               `json:"full_name"`
+	Methods  []*MethodDescription `json:"methods"`
+}
+
+type MethodDescription struct {
+	Name            string `json:"name"`
+	FullName        string `json:"full_name"`
+	InputType       string `json:"input_type"`
+	OutputType      string `json:"output_type"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+	// Only set when describing the method itself
+	Request  *MessageDescription `json:"request,omitempty"`
+	Response *MessageDescription `json:"response,omitempty"`
+}
+
+type MessageDescription struct {
+	Name     string                `json:"name"`
+	FullName string                `json:"full_name"`
+	Fields   []*FieldDescription   `json:"fields"`
+	Messages []*MessageDescription `json:"messages,omitempty"`
+	Enums    []*EnumDescription    `json:"enums,omitempty"`
+}
+
+type FieldDescription struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Number   int32  `json:"number"`
+	// Type as used in .proto files, like "string", "message" or "map"
+	Type string `json:"type"`
+	// Message or enum type name
+	TypeName string `json:"type_name,omitempty"`
+	// "optional", "required" or "repeated"
+	Label    string `json:"label"`
+	JSONName string `json:"json_name"`
+	OneOf    string `json:"oneof,omitempty"`
+	// Only set for map fields
+	MapKeyType       string `json:"map_key_type,omitempty"`
+	MapValueType     string `json:"map_value_type,omitempty"`
+	MapValueTypeName string `json:"map_value_type_name,omitempty"`
+}
+
+type EnumDescription struct {
+	Name     string                  `json:"name"`
+	FullName string                  `json:"full_name"`
+	Values   []*EnumValueDescription `json:"values"`
+}
+
+type EnumValueDescription struct {
+	Name   string `json:"name"`
+	Number int32  `json:"number"`
+}
+
+func NewFileDescription(file *desc.FileDescriptor) *FileDescription {
+	ret := &FileDescription{
+		Name:    file.GetName(),
+		Package: file.GetPackage(),
+	}
+	for _, svc := range file.GetServices() {
+		ret.Services = append(ret.Services, NewServiceDescription(svc))
+	}
+	for _, msg := range file.GetMessageTypes() {
+		ret.Messages = append(ret.Messages, NewMessageDescription(msg))
+	}
+	for _, enum := range file.GetEnumTypes() {
+		ret.Enums = append(ret.Enums, NewEnumDescription(enum))
+	}
+	return ret
+}
+
+func NewServiceDescription(svc *desc.ServiceDescriptor) *ServiceDescription {
+	ret := &ServiceDescription{
+		Name:     svc.GetName(),
+		FullName: svc.GetFullyQualifiedName(),
+		Methods:  []*MethodDescription{},
+	}
+	for _, mtd := range svc.GetMethods() {
+		ret.Methods = append(ret.Methods, NewMethodDescription(mtd, false))
+	}
+	return ret
+}
+
+// If complete is true, the request and response messages are also described
+func NewMethodDescription(mtd *desc.MethodDescriptor, complete bool) *MethodDescription {
+	ret := &MethodDescription{
+		Name:            mtd.GetName(),
+		FullName:        mtd.GetFullyQualifiedName(),
+		InputType:       mtd.GetInputType().GetFullyQualifiedName(),
+		OutputType:      mtd.GetOutputType().GetFullyQualifiedName(),
+		ClientStreaming: mtd.IsClientStreaming(),
+		ServerStreaming: mtd.IsServerStreaming(),
+	}
+	if complete {
+		ret.Request = NewMessageDescription(mtd.GetInputType())
+		ret.Response = NewMessageDescription(mtd.GetOutputType())
+	}
+	return ret
+}
+
+func NewMessageDescription(msg *desc.MessageDescriptor) *MessageDescription {
+	ret := &MessageDescription{
+		Name:     msg.GetName(),
+		FullName: msg.GetFullyQualifiedName(),
+		Fields:   []*FieldDescription{},
+	}
+	for _, fld := range msg.GetFields() {
+		ret.Fields = append(ret.Fields, NewFieldDescription(fld))
+	}
+	for _, nested := range msg.GetNestedMessageTypes() {
+		// map entries are described in the map field
+		if nested.IsMapEntry() {
+			continue
+		}
+		ret.Messages = append(ret.Messages, NewMessageDescription(nested))
+	}
+	for _, enum := range msg.GetNestedEnumTypes() {
+		ret.Enums = append(ret.Enums, NewEnumDescription(enum))
+	}
+	return ret
+}
+
+func NewFieldDescription(fld *desc.FieldDescriptor) *FieldDescription {
+	ret := &FieldDescription{
+		Name:     fld.GetName(),
+		FullName: fld.GetFullyQualifiedName(),
+		Number:   fld.GetNumber(),
+		Type:     fieldTypeName(fld),
+		TypeName: fieldTypeRefName(fld),
+		Label:    fieldLabelName(fld),
+		JSONName: fld.GetJSONName(),
+	}
+	if fld.IsMap() {
+		ret.TypeName = ""
+		ret.MapKeyType = fieldTypeName(fld.GetMapKeyType())
+		ret.MapValueType = fieldTypeName(fld.GetMapValueType())
+		ret.MapValueTypeName = fieldTypeRefName(fld.GetMapValueType())
+	}
+	if fld.GetOneOf() != nil {
+		ret.OneOf = fld.GetOneOf().GetName()
+	}
+	return ret
+}
+
+func NewEnumDescription(enum *desc.EnumDescriptor) *EnumDescription {
+	ret := &EnumDescription{
+		Name:     enum.GetName(),
+		FullName: enum.GetFullyQualifiedName(),
+		Values:   []*EnumValueDescription{},
+	}
+	for _, ev := range enum.GetValues() {
+		ret.Values = append(ret.Values, &EnumValueDescription{
+			Name:   ev.GetName(),
+			Number: ev.GetNumber(),
+		})
+	}
+	return ret
+}
+
+//
+// ServiceListOutput - JSON
+//
+// Outputs {"services": ["name", ...]}
+//
+type JSONServiceListOutput struct {
+	Out    io.Writer
+	Indent string
+}
+
+func NewJSONServiceListOutput(out io.Writer) *JSONServiceListOutput {
+	return &JSONServiceListOutput{
+		Out:    out,
+		Indent: "  ",
+	}
+}
+
+func (d *JSONServiceListOutput) OutputServiceList(services []string) error {
+	if services == nil {
+		services = []string{}
+	}
+	return writeJSON(d.Out, d.Indent, struct {
+		Services []string `json:"services"`
+	}{services})
+}
+
+//
+// ServiceOutput - JSON
+//
+type JSONServiceOutput struct {
+	Out    io.Writer
+	Indent string
+}
+
+func NewJSONServiceOutput(out io.Writer) *JSONServiceOutput {
+	return &JSONServiceOutput{
+		Out:    out,
+		Indent: "  ",
+	}
+}
+
+func (d *JSONServiceOutput) OutputService(service *desc.ServiceDescriptor) error {
+	return writeJSON(d.Out, d.Indent, NewServiceDescription(service))
+}
+
+//
+// DescribeOutput - JSON
+//
+// Outputs the description of the descriptor, describing a method also describes its request and response messages.
+//
+type JSONDescribeOutput struct {
+	Out    io.Writer
+	Indent string
+}
+
+func NewJSONDescribeOutput(out io.Writer) *JSONDescribeOutput {
+	return &JSONDescribeOutput{
+		Out:    out,
+		Indent: "  ",
+	}
+}
+
+func (d *JSONDescribeOutput) OutputDescribe(descriptor desc.Descriptor) error {
+	var value interface{}
+	switch sd := descriptor.(type) {
+	case *desc.FileDescriptor:
+		value = NewFileDescription(sd)
+	case *desc.ServiceDescriptor:
+		value = NewServiceDescription(sd)
+	case *desc.MethodDescriptor:
+		value = NewMethodDescription(sd, true)
+	case *desc.MessageDescriptor:
+		value = NewMessageDescription(sd)
+	case *desc.EnumDescriptor:
+		value = NewEnumDescription(sd)
+	case *desc.FieldDescriptor:
+		value = NewFieldDescription(sd)
+	default:
+		return fmt.Errorf("Unknown descriptor type %T for %s", descriptor, descriptor.GetFullyQualifiedName())
+	}
+	return writeJSON(d.Out, d.Indent, value)
+}
+
+// Writes the value as JSON followed by a new line
+func writeJSON(out io.Writer, indent string, value interface{}) error {
+	var b []byte
+	var err error
+	if indent != "" {
+		b, err = json.MarshalIndent(value, "", indent)
+	} else {
+		b, err = json.Marshal(value)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, string(b))
+	return err
+}
@@ -0,0 +1,153 @@
+package grpcget
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONDescribeOutput(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{symbol: "test.RecordService", want: `{"name":"RecordService","full_name":"test.RecordService","methods":[` +
+			`{"name":"Get","full_name":"test.RecordService.Get","input_type":"test.Item","output_type":"test.Record","client_streaming":false,"server_streaming":false},` +
+			`{"name":"Watch","full_name":"test.RecordService.Watch","input_type":"test.Item","output_type":"test.Record","client_streaming":false,"server_streaming":true},` +
+			`{"name":"Upload","full_name":"test.RecordService.Upload","input_type":"test.Record","output_type":"test.Items","client_streaming":true,"server_streaming":false},` +
+			`{"name":"Sync","full_name":"test.RecordService.Sync","input_type":"test.Record","output_type":"test.Record","client_streaming":true,"server_streaming":true}]}`},
+		{symbol: "test.Nested", want: `{"name":"Nested","full_name":"test.Nested","fields":[` +
+			`{"name":"child","full_name":"test.Nested.child","number":1,"type":"message","type_name":"test.Nested.Child","label":"optional","json_name":"child"},` +
+			`{"name":"kind","full_name":"test.Nested.kind","number":2,"type":"enum","type_name":"test.Nested.Kind","label":"optional","json_name":"kind"},` +
+			`{"name":"json_field","full_name":"test.Nested.json_field","number":3,"type":"string","label":"optional","json_name":"custom"},` +
+			`{"name":"children","full_name":"test.Nested.children","number":4,"type":"map","label":"repeated","json_name":"children","map_key_type":"string","map_value_type":"message","map_value_type_name":"test.Nested.Child"},` +
+			`{"name":"a","full_name":"test.Nested.a","number":5,"type":"string","label":"optional","json_name":"a","oneof":"choice"},` +
+			`{"name":"b","full_name":"test.Nested.b","number":6,"type":"int32","label":"optional","json_name":"b","oneof":"choice"}],` +
+			`"messages":[{"name":"Child","full_name":"test.Nested.Child","fields":[{"name":"v","full_name":"test.Nested.Child.v","number":1,"type":"int32","label":"optional","json_name":"v"}]}],` +
+			`"enums":[{"name":"Kind","full_name":"test.Nested.Kind","values":[{"name":"KIND_UNKNOWN","number":0},{"name":"KIND_A","number":1}]}]}`},
+		{symbol: "test.R_string.v", want: `{"name":"v","full_name":"test.R_string.v","number":1,"type":"string","label":"repeated","json_name":"v"}`},
+		{symbol: "test.M_string_enum.v", want: `{"name":"v","full_name":"test.M_string_enum.v","number":1,"type":"map","label":"repeated","json_name":"v",` +
+			`"map_key_type":"string","map_value_type":"enum","map_value_type_name":"test.Status"}`},
+		{symbol: "test.Status", want: `{"name":"Status","full_name":"test.Status","values":[` +
+			`{"name":"STATUS_UNKNOWN","number":0},{"name":"STATUS_ACTIVE","number":1},{"name":"STATUS_NOT_ACTIVE","number":2}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			d := fd.FindSymbol(tt.symbol)
+			if d == nil {
+				t.Fatalf("Symbol %s not found in test proto", tt.symbol)
+			}
+
+			var b bytes.Buffer
+			output := NewJSONDescribeOutput(&b)
+			output.Indent = ""
+			err := output.OutputDescribe(d)
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want+"\n" {
+				t.Errorf("Output is %s, want %s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONDescribeOutputMethod(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	var b bytes.Buffer
+	err := NewJSONDescribeOutput(&b).OutputDescribe(fd.FindSymbol("test.RecordService.Upload"))
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+
+	var md MethodDescription
+	err = json.Unmarshal(b.Bytes(), &md)
+	if err != nil {
+		t.Fatalf("Error parsing output: %v", err)
+	}
+	if !md.ClientStreaming || md.ServerStreaming {
+		t.Errorf("Streaming is client %v server %v, want client true server false", md.ClientStreaming, md.ServerStreaming)
+	}
+	// the request and response messages are described
+	if md.Request == nil || md.Request.FullName != "test.Record" || len(md.Request.Fields) != 9 {
+		t.Errorf("Request is %+v, want test.Record with 9 fields", md.Request)
+	}
+	if md.Response == nil || md.Response.FullName != "test.Items" || len(md.Response.Fields) != 1 {
+		t.Errorf("Response is %+v, want test.Items with 1 field", md.Response)
+	}
+}
+
+func TestJSONDescribeOutputFile(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	var b bytes.Buffer
+	err := NewJSONDescribeOutput(&b).OutputDescribe(fd)
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+
+	var fdesc FileDescription
+	err = json.Unmarshal(b.Bytes(), &fdesc)
+	if err != nil {
+		t.Fatalf("Error parsing output: %v", err)
+	}
+	if fdesc.Name != "test.proto" || fdesc.Package != "test" {
+		t.Errorf("File is %s package %s, want test.proto package test", fdesc.Name, fdesc.Package)
+	}
+	if len(fdesc.Services) != 1 || len(fdesc.Messages) != len(fd.GetMessageTypes()) || len(fdesc.Enums) != len(fd.GetEnumTypes()) {
+		t.Errorf("File has %d services, %d messages and %d enums, want 1, %d and %d", len(fdesc.Services), len(fdesc.Messages),
+			len(fdesc.Enums), len(fd.GetMessageTypes()), len(fd.GetEnumTypes()))
+	}
+	// map entries are not described as messages
+	for _, msg := range fdesc.Messages {
+		if msg.FullName == "test.M_string_int32" && len(msg.Messages) != 0 {
+			t.Errorf("Map entry of %s described as a message", msg.FullName)
+		}
+	}
+}
+
+func TestJSONServiceListOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []string
+		want     string
+	}{
+		{name: "services", services: []string{"a.A", "b.B"}, want: `{"services":["a.A","b.B"]}`},
+		{name: "empty", want: `{"services":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			output := NewJSONServiceListOutput(&b)
+			output.Indent = ""
+			err := output.OutputServiceList(tt.services)
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want+"\n" {
+				t.Errorf("Output is %s, want %s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONServiceOutputIndent(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	var b bytes.Buffer
+	err := NewJSONServiceOutput(&b).OutputService(fd.FindService("test.RecordService"))
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+
+	// indented by default
+	want := "{\n  \"name\": \"RecordService\",\n  \"full_name\": \"test.RecordService\",\n  \"methods\": [\n    {\n      \"name\": \"Get\",\n"
+	if !bytes.HasPrefix(b.Bytes(), []byte(want)) {
+		t.Errorf("Output is %q, want prefix %q", b.String(), want)
+	}
+}
@@ -0,0 +1,231 @@
+package grpcget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// register the google.protobuf.FieldMask type, the other well-known types are registered by the dynamic package
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+//
+// InvokeOutput - JSON
+//
+// Outputs the response as canonical proto3 JSON. When Indent is blank, each message is output in a single line,
+// so streaming responses are output as newline-delimited JSON. Any values are resolved with the DynMsgHelper.
+// The response is marshaled with the protojson package, which supports the JSON mapping of all the well-known types.
+//
+type JSONInvokeOutput struct {
+	Out io.Writer
+	// Indentation, blank outputs each message in a single line
+	Indent string
+	// Use the original proto field names instead of the lowerCamelCase JSON names
+	OrigName bool
+	// Output fields with default values
+	EmitDefaults bool
+	// Output enum values as numbers instead of names
+	EnumsAsInts bool
+}
+
+func NewJSONInvokeOutput(out io.Writer) *JSONInvokeOutput {
+	return &JSONInvokeOutput{
+		Out:    out,
+		Indent: "  ",
+	}
+}
+
+func (d *JSONInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	m := protojson.MarshalOptions{
+		UseProtoNames:   d.OrigName,
+		EmitUnpopulated: d.EmitDefaults,
+		UseEnumNumbers:  d.EnumsAsInts,
+		Resolver:        &jsonTypeResolver{dmh: dmh},
+	}
+
+	msg, err := jsonMessage(value)
+	if err != nil {
+		return err
+	}
+
+	b, err := m.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// the protojson whitespace is not stable, so the output is always formatted afterwards
+	var out bytes.Buffer
+	if d.Indent != "" {
+		err = json.Indent(&out, b, "", d.Indent)
+	} else {
+		err = json.Compact(&out, b)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(d.Out, out.String())
+	return err
+}
+
+// Converts a message to the google.golang.org/protobuf API used by protojson. Dynamic messages are converted to
+// dynamicpb messages.
+func jsonMessage(msg proto.Message) (protoreflect.ProtoMessage, error) {
+	dmsg, ok := msg.(*dynamic.Message)
+	if !ok {
+		return proto.MessageV2(msg), nil
+	}
+
+	b, err := dmsg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	ret := dynamicpb.NewMessage(dmsg.GetMessageDescriptor().UnwrapMessage())
+	err = protov2.Unmarshal(b, ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Resolves the types of google.protobuf.Any values for protojson. Registered types, like the well-known types, use
+// their generated types, and other types are resolved with the DynMsgHelper.
+type jsonTypeResolver struct {
+	dmh *DynMsgHelper
+}
+
+func (r *jsonTypeResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	return r.FindMessageByURL(string(name))
+}
+
+func (r *jsonTypeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByURL(url); err == nil {
+		return mt, nil
+	}
+
+	md, err := r.dmh.FindAnyMessageDescriptor(url)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicpb.NewMessageType(md.UnwrapMessage()), nil
+}
+
+func (r *jsonTypeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (r *jsonTypeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// Resolves the types of google.protobuf.Any values for jsonpb. Registered types, like the well-known types, use
+// their generated types, and other types are resolved with the DynMsgHelper.
+type jsonAnyResolver struct {
+	dmh *DynMsgHelper
+}
+
+func (r *jsonAnyResolver) Resolve(typeURL string) (proto.Message, error) {
+	name := typeURL[strings.LastIndex(typeURL, "/")+1:]
+	if mt := proto.MessageType(name); mt != nil {
+		return reflect.New(mt.Elem()).Interface().(proto.Message), nil
+	}
+
+	md, err := r.dmh.FindAnyMessageDescriptor(typeURL)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewMessage(md), nil
+}
+
+//
+// InvokeErrorOutput - JSON
+//
+// Outputs the status of a failed invoke as {"code": "InvalidArgument", "message": "...", "details": [...]}.
+// The details are output as the JSON of google.protobuf.Any, with the "@type" field and the message fields.
+// Details of unknown types have the base64 encoded "value" field. Nothing is output if there are no details, as the
+// error itself is returned by Invoke.
+//
+type JSONInvokeErrorOutput struct {
+	Out io.Writer
+	// Indentation, blank outputs in a single line
+	Indent string
+	// Use the original proto field names instead of the lowerCamelCase JSON names
+	OrigName bool
+}
+
+func NewJSONInvokeErrorOutput(out io.Writer) *JSONInvokeErrorOutput {
+	return &JSONInvokeErrorOutput{
+		Out:    out,
+		Indent: "  ",
+	}
+}
+
+func (d *JSONInvokeErrorOutput) OutputInvokeError(dmh *DynMsgHelper, st *status.Status, details []proto.Message) error {
+	if len(details) == 0 {
+		return nil
+	}
+
+	m := &jsonpb.Marshaler{
+		OrigName: d.OrigName,
+	}
+
+	value := struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Details []json.RawMessage `json:"details"`
+	}{
+		Code:    st.Code().String(),
+		Message: st.Message(),
+		Details: []json.RawMessage{},
+	}
+
+	for _, detail := range details {
+		var typeURL, str string
+		var err error
+		switch xdetail := detail.(type) {
+		case *any.Any:
+			typeURL = xdetail.GetTypeUrl()
+			var b []byte
+			b, err = json.Marshal(struct {
+				Value []byte `json:"value"`
+			}{xdetail.GetValue()})
+			str = string(b)
+		case *dynamic.Message:
+			typeURL = "type.googleapis.com/" + xdetail.GetMessageDescriptor().GetFullyQualifiedName()
+			str, err = m.MarshalToString(xdetail)
+		default:
+			typeURL = "type.googleapis.com/" + proto.MessageName(detail)
+			str, err = m.MarshalToString(detail)
+		}
+		if err != nil {
+			return err
+		}
+
+		// add the @type field to the message object
+		typeJSON, err := json.Marshal(typeURL)
+		if err != nil {
+			return err
+		}
+		fields := bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace([]byte(str)), []byte("{")))
+		if !bytes.Equal(fields, []byte("}")) {
+			fields = append([]byte(","), fields...)
+		}
+		value.Details = append(value.Details, json.RawMessage(`{"@type":`+string(typeJSON)+string(fields)))
+	}
+
+	return writeJSON(d.Out, d.Indent, value)
+}
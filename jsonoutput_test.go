@@ -0,0 +1,58 @@
+package grpcget
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestJSONInvokeErrorOutput(t *testing.T) {
+	badRequest := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "name", Description: "must not be empty"},
+	}}
+	withDetails, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(badRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := withDetails.Proto()
+	unknown.Details = append(unknown.Details, &any.Any{TypeUrl: "type.googleapis.com/test.Unknown", Value: []byte{1, 2}})
+	withUnknown := status.FromProto(unknown)
+
+	tests := []struct {
+		name     string
+		st       *status.Status
+		origName bool
+		want     string
+	}{
+		{name: "no details", st: status.New(codes.NotFound, "not found"), want: ""},
+		{name: "details", st: withDetails,
+			want: `{"code":"InvalidArgument","message":"invalid request","details":[` +
+				`{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"name","description":"must not be empty"}]}]}` + "\n"},
+		{name: "orig name", st: withDetails, origName: true,
+			want: `{"code":"InvalidArgument","message":"invalid request","details":[` +
+				`{"@type":"type.googleapis.com/google.rpc.BadRequest","field_violations":[{"field":"name","description":"must not be empty"}]}]}` + "\n"},
+		{name: "unknown type", st: withUnknown,
+			want: `{"code":"InvalidArgument","message":"invalid request","details":[` +
+				`{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"name","description":"must not be empty"}]},` +
+				`{"@type":"type.googleapis.com/test.Unknown","value":"AQI="}]}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			o := NewJSONInvokeErrorOutput(&b)
+			o.Indent = ""
+			o.OrigName = tt.origName
+			if err := o.OutputInvokeError(NewDynMsgHelper(), tt.st, DecodeStatusDetails(nil, tt.st)); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
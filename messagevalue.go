@@ -0,0 +1,243 @@
+package grpcget
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+)
+
+//
+// MessageValue
+//
+// Generic view of a message, with the fields that are set in definition order, for outputs that don't use
+// the protobuf encodings. Field values are converted with the DynMsgHelper field getters when available.
+//
+type MessageValue struct {
+	Descriptor *desc.MessageDescriptor
+	Fields     []*MessageFieldValue
+}
+
+// Value of a message field. Value is one of:
+//   - string, if converted by a DynMsgHelper field getter (Getter is true)
+//   - []interface{} for repeated fields, with the values of the elements
+//   - []*MapEntryValue for map fields, sorted by key
+//   - *WellKnownValue for well-known type messages
+//   - *MessageValue for other messages
+//   - EnumValue for enums
+//   - the scalar value (string, []byte, bool, int32, int64, uint32, uint64, float32 or float64)
+type MessageFieldValue struct {
+	Field  *desc.FieldDescriptor
+	Value  interface{}
+	Getter bool
+}
+
+// Entry of a map field
+type MapEntryValue struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Value of an enum field
+type EnumValue struct {
+	Number int32
+	// Blank if the number is not a value of the enum
+	Name string
+	Enum *desc.EnumDescriptor
+}
+
+func (v EnumValue) String() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return fmt.Sprintf("%d", v.Number)
+}
+
+// Returns the field with the name, or nil if it is not set
+func (m *MessageValue) FieldByName(name string) *MessageFieldValue {
+	for _, f := range m.Fields {
+		if f.Field.GetName() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Returns the value of an unset field, in the representation of MessageFieldValue.Value: empty lists for repeated
+// fields and maps, the default value for scalars and enums, and nil for messages
+func FieldDefaultValue(fld *desc.FieldDescriptor) interface{} {
+	if fld.IsMap() {
+		return []*MapEntryValue{}
+	}
+	if fld.IsRepeated() {
+		return []interface{}{}
+	}
+	switch fld.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE, descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return nil
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		ev := EnumValue{Number: fld.GetDefaultValue().(int32), Enum: fld.GetEnumType()}
+		if evd := fld.GetEnumType().FindValueByNumber(ev.Number); evd != nil {
+			ev.Name = evd.GetName()
+		}
+		return ev
+	}
+	return fld.GetDefaultValue()
+}
+
+// Formats a scalar value as text: strings as is, enums by name, bytes as base64, and floats in the shortest
+// representation
+func formatScalarValue(value interface{}) string {
+	switch xvalue := value.(type) {
+	case string:
+		return xvalue
+	case EnumValue:
+		return xvalue.String()
+	case []byte:
+		return base64.StdEncoding.EncodeToString(xvalue)
+	case float32:
+		return strconv.FormatFloat(float64(xvalue), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(xvalue, 'g', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// Returns the value of numbers, enums and well-known number wrappers as a float64, or ok false for other values
+func numberValue(value interface{}) (float64, bool) {
+	switch xvalue := value.(type) {
+	case int32:
+		return float64(xvalue), true
+	case int64:
+		return float64(xvalue), true
+	case uint32:
+		return float64(xvalue), true
+	case uint64:
+		return float64(xvalue), true
+	case float32:
+		return float64(xvalue), true
+	case float64:
+		return xvalue, true
+	case EnumValue:
+		return float64(xvalue.Number), true
+	case *WellKnownValue:
+		return numberValue(xvalue.Value)
+	}
+	return 0, false
+}
+
+// Converts a message to a MessageValue. Messages that are not *dynamic.Message are converted to it first.
+func (h *DynMsgHelper) MessageValue(msg proto.Message) (*MessageValue, error) {
+	dmsg, err := asDynamicMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &MessageValue{
+		Descriptor: dmsg.GetMessageDescriptor(),
+	}
+
+	for _, fld := range dmsg.GetKnownFields() {
+		if !dmsg.HasField(fld) {
+			continue
+		}
+
+		// check if has getter plugin
+		has_getter, getter_value, err := h.GetFieldValue(dmsg, fld)
+		if err != nil {
+			return nil, err
+		}
+		if has_getter {
+			ret.Fields = append(ret.Fields, &MessageFieldValue{Field: fld, Value: getter_value, Getter: true})
+			continue
+		}
+
+		var value interface{}
+		if fld.IsMap() {
+			var entries []*MapEntryValue
+			for k, v := range dmsg.GetField(fld).(map[interface{}]interface{}) {
+				ev, err := h.fieldElementValue(fld.GetMapValueType(), v)
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, &MapEntryValue{Key: k, Value: ev})
+			}
+			sort.Slice(entries, func(i, j int) bool {
+				return mapKeyLess(entries[i].Key, entries[j].Key)
+			})
+			value = entries
+		} else if fld.IsRepeated() {
+			var items []interface{}
+			for ridx := 0; ridx < dmsg.FieldLength(fld); ridx++ {
+				ev, err := h.fieldElementValue(fld, dmsg.GetRepeatedField(fld, ridx))
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, ev)
+			}
+			value = items
+		} else {
+			value, err = h.fieldElementValue(fld, dmsg.GetField(fld))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ret.Fields = append(ret.Fields, &MessageFieldValue{Field: fld, Value: value})
+	}
+
+	return ret, nil
+}
+
+// Converts a single (non-repeated) value of a field
+func (h *DynMsgHelper) fieldElementValue(fld *desc.FieldDescriptor, value interface{}) (interface{}, error) {
+	switch fld.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE, descriptor.FieldDescriptorProto_TYPE_GROUP:
+		msg, ok := value.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("Unknown message type for field %s", fld.GetName())
+		}
+		mv, err := h.MessageValue(msg)
+		if err != nil {
+			return nil, err
+		}
+		is_wkt, wv, err := h.WellKnownTypeValue(msg)
+		if err != nil {
+			return nil, err
+		}
+		if is_wkt {
+			return &WellKnownValue{Message: mv, Value: wv}, nil
+		}
+		return mv, nil
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		ev := EnumValue{Number: value.(int32), Enum: fld.GetEnumType()}
+		if evd := fld.GetEnumType().FindValueByNumber(ev.Number); evd != nil {
+			ev.Name = evd.GetName()
+		}
+		return ev, nil
+	}
+	return value, nil
+}
+
+// Compares map keys, which are all of the same scalar type
+func mapKeyLess(a, b interface{}) bool {
+	switch av := a.(type) {
+	case string:
+		return av < b.(string)
+	case bool:
+		return !av && b.(bool)
+	case int32:
+		return av < b.(int32)
+	case int64:
+		return av < b.(int64)
+	case uint32:
+		return av < b.(uint32)
+	case uint64:
+		return av < b.(uint64)
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
@@ -0,0 +1,40 @@
+package grpcget
+
+import (
+	"io"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+)
+
+//
+// DescribeOutput - Proto
+//
+// Outputs descriptors as .proto source. Files are output completely, including imports and options, other
+// descriptors only output their own definition. Comments are output if the descriptors have source info.
+//
+type ProtoDescribeOutput struct {
+	Out     io.Writer
+	Printer *protoprint.Printer
+}
+
+func NewProtoDescribeOutput(out io.Writer) *ProtoDescribeOutput {
+	return &ProtoDescribeOutput{
+		Out:     out,
+		Printer: &protoprint.Printer{},
+	}
+}
+
+func (d *ProtoDescribeOutput) OutputDescribe(descriptor desc.Descriptor) error {
+	if fd, ok := descriptor.(*desc.FileDescriptor); ok {
+		return d.Printer.PrintProtoFile(fd, d.Out)
+	}
+
+	str, err := d.Printer.PrintProtoToString(descriptor)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(d.Out, str)
+	return err
+}
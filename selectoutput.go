@@ -0,0 +1,488 @@
+package grpcget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//
+// Select path
+//
+// Path expression that selects values from a message, using the same dotted conventions as
+// DynMsgHelper.SetParamValue, where repeated fields are indexed and map fields keyed with dotted segments,
+// like "items.0.id" and "labels.env". Brackets can also be used:
+//
+//   items[0].id              element of a repeated field, negative indexes count from the end
+//   items[*].id              all elements of a repeated field or values of a map, "items.*.id" is the same
+//   labels["env"]            value of a map key
+//   items[?state=="ACTIVE"]  elements of a repeated field or values of a map where the path inside them is
+//                            equal to the value. The operator can be == or !=, and the value a quoted string,
+//                            a number, true, false or an enum name, with or without the enum prefix.
+//                            Use "@" as the path to compare the element itself, like tags[?@=="a"].
+//
+// Well-known types are selected in their JSON mapping, so Struct fields are selected like map keys and the
+// packed message of Any like a message field, or with "value" if the packed message is a well-known type.
+//
+type SelectPath struct {
+	Expr  string
+	steps []*selectStep
+}
+
+type selectStepKind int
+
+const (
+	selectName selectStepKind = iota
+	selectIndex
+	selectKey
+	selectAll
+	selectFilter
+)
+
+type selectStep struct {
+	kind  selectStepKind
+	name  string
+	index int
+	// filter
+	filterSteps []*selectStep
+	filterNot   bool
+	filterValue string
+}
+
+// Parses a select path expression
+func ParseSelectPath(expr string) (*SelectPath, error) {
+	p := &selectParser{expr: expr}
+	steps, err := p.parsePath(false)
+	if err == nil && p.pos < len(p.expr) {
+		err = fmt.Errorf("unexpected %q at position %d", p.expr[p.pos], p.pos+1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid select path %q: %v", expr, err)
+	}
+
+	return &SelectPath{
+		Expr:  expr,
+		steps: steps,
+	}, nil
+}
+
+// Returns the values selected from the message, in the MessageValue representation
+func (s *SelectPath) Select(msg *MessageValue) ([]interface{}, error) {
+	return selectFrom(s.steps, msg)
+}
+
+func selectFrom(steps []*selectStep, value interface{}) ([]interface{}, error) {
+	current := []interface{}{value}
+	for _, step := range steps {
+		var next []interface{}
+		for _, v := range current {
+			values, err := step.apply(v)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, values...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func (st *selectStep) apply(value interface{}) ([]interface{}, error) {
+	// select inside well-known types
+	if wv, ok := value.(*WellKnownValue); ok {
+		value = wv.Value
+	}
+	if av, ok := value.(*AnyValue); ok {
+		if av.Message == nil {
+			return nil, fmt.Errorf("Unknown message type %s", av.TypeURL)
+		}
+		if av.WellKnown != nil {
+			if st.kind != selectName || st.name != "value" {
+				return nil, fmt.Errorf("The well-known type %s in Any must be selected with \"value\"", av.TypeURL)
+			}
+			return []interface{}{av.WellKnown}, nil
+		}
+		value = av.Message
+	}
+
+	switch xvalue := value.(type) {
+	case *MessageValue:
+		switch st.kind {
+		case selectName:
+			if fv := xvalue.FieldByName(st.name); fv != nil {
+				return []interface{}{fv.Value}, nil
+			}
+			fld := xvalue.Descriptor.FindFieldByName(st.name)
+			if fld == nil {
+				return nil, fmt.Errorf("Field %s not found in message %s", st.name, xvalue.Descriptor.GetFullyQualifiedName())
+			}
+			// unset messages are not selected
+			if value := FieldDefaultValue(fld); value != nil {
+				return []interface{}{value}, nil
+			}
+			return nil, nil
+		case selectAll:
+			var ret []interface{}
+			for _, f := range xvalue.Fields {
+				ret = append(ret, f.Value)
+			}
+			return ret, nil
+		}
+		return nil, fmt.Errorf("Fields of message %s must be selected by name", xvalue.Descriptor.GetFullyQualifiedName())
+	case []interface{}:
+		switch st.kind {
+		case selectName:
+			// dotted index
+			idx, err := strconv.Atoi(st.name)
+			if err != nil {
+				return nil, fmt.Errorf("Repeated values must be selected with an index or *, not %q", st.name)
+			}
+			return (&selectStep{kind: selectIndex, index: idx}).apply(xvalue)
+		case selectIndex:
+			idx := st.index
+			if idx < 0 {
+				idx += len(xvalue)
+			}
+			if idx < 0 || idx >= len(xvalue) {
+				return nil, nil
+			}
+			return []interface{}{xvalue[idx]}, nil
+		case selectAll:
+			return xvalue, nil
+		case selectFilter:
+			var ret []interface{}
+			for _, item := range xvalue {
+				match, err := st.filterMatches(item)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					ret = append(ret, item)
+				}
+			}
+			return ret, nil
+		}
+		return nil, errors.New("Repeated values must be selected with an index or *")
+	case []*MapEntryValue:
+		switch st.kind {
+		case selectName, selectKey, selectIndex:
+			key := st.name
+			if st.kind == selectIndex {
+				key = strconv.Itoa(st.index)
+			}
+			for _, entry := range xvalue {
+				if fmt.Sprint(entry.Key) == key {
+					return []interface{}{entry.Value}, nil
+				}
+			}
+			return nil, nil
+		case selectAll, selectFilter:
+			var ret []interface{}
+			for _, entry := range xvalue {
+				if st.kind == selectFilter {
+					match, err := st.filterMatches(entry.Value)
+					if err != nil {
+						return nil, err
+					}
+					if !match {
+						continue
+					}
+				}
+				ret = append(ret, entry.Value)
+			}
+			return ret, nil
+		}
+	}
+
+	return nil, errors.New("Values cannot be selected inside a scalar value")
+}
+
+func (st *selectStep) filterMatches(value interface{}) (bool, error) {
+	values, err := selectFrom(st.filterSteps, value)
+	if err != nil {
+		return false, err
+	}
+
+	match := false
+	for _, v := range values {
+		if selectValueEquals(v, st.filterValue) {
+			match = true
+			break
+		}
+	}
+	return match != st.filterNot, nil
+}
+
+// Compares a selected value with a filter value
+func selectValueEquals(value interface{}, cmp string) bool {
+	switch xvalue := value.(type) {
+	case *WellKnownValue:
+		return selectValueEquals(xvalue.Value, cmp)
+	case EnumValue:
+		if xvalue.Enum != nil {
+			// same names as the params, like ACTIVE for STATUS_ACTIVE
+			n, err := ParseEnumValue(xvalue.Enum, cmp)
+			return err == nil && n == xvalue.Number
+		}
+		return cmp == strconv.Itoa(int(xvalue.Number)) || strings.EqualFold(xvalue.Name, cmp)
+	case string:
+		return xvalue == cmp
+	case bool:
+		return strconv.FormatBool(xvalue) == cmp
+	case nil:
+		return cmp == "null"
+	}
+
+	if f, ok := numberValue(value); ok {
+		cf, err := strconv.ParseFloat(cmp, 64)
+		return err == nil && f == cf
+	}
+	return SelectRawValue(value) == cmp
+}
+
+// Formats a selected value for raw output: strings without quotes, enums by name, bytes as base64,
+// and messages, lists and maps as JSON
+func SelectRawValue(value interface{}) string {
+	switch xvalue := value.(type) {
+	case *WellKnownValue:
+		return SelectRawValue(xvalue.Value)
+	case string, EnumValue, []byte, bool, int32, int64, uint32, uint64, float32, float64:
+		return formatScalarValue(xvalue)
+	}
+
+	b, err := json.Marshal(TemplateValue(value))
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+//
+// Select path parser
+//
+type selectParser struct {
+	expr string
+	pos  int
+}
+
+func (p *selectParser) peek() byte {
+	if p.pos < len(p.expr) {
+		return p.expr[p.pos]
+	}
+	return 0
+}
+
+// Parses the steps of a path, until the end or an operator inside a filter
+func (p *selectParser) parsePath(inFilter bool) ([]*selectStep, error) {
+	var steps []*selectStep
+
+	if inFilter && p.peek() == '@' {
+		// the element itself
+		p.pos++
+	} else if p.peek() != '[' {
+		step, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+
+	for {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			step, err := p.parseSegment()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		case '[':
+			p.pos++
+			step, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			if p.peek() != ']' {
+				return nil, fmt.Errorf("missing ] at position %d", p.pos+1)
+			}
+			p.pos++
+			steps = append(steps, step)
+		default:
+			return steps, nil
+		}
+	}
+}
+
+// Parses a dotted segment: a field name, map key, index or *
+func (p *selectParser) parseSegment() (*selectStep, error) {
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(".[]=! ", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	name := p.expr[start:p.pos]
+	if name == "" {
+		return nil, fmt.Errorf("missing field name at position %d", start+1)
+	}
+	if name == "*" {
+		return &selectStep{kind: selectAll}, nil
+	}
+	return &selectStep{kind: selectName, name: name}, nil
+}
+
+// Parses the contents of brackets
+func (p *selectParser) parseBracket() (*selectStep, error) {
+	switch p.peek() {
+	case '*':
+		p.pos++
+		return &selectStep{kind: selectAll}, nil
+	case '"':
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &selectStep{kind: selectKey, name: key}, nil
+	case '?':
+		p.pos++
+		return p.parseFilter()
+	}
+
+	start := p.pos
+	for p.pos < len(p.expr) && p.expr[p.pos] != ']' {
+		p.pos++
+	}
+	idx, err := strconv.Atoi(strings.TrimSpace(p.expr[start:p.pos]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid index %q, use quotes for map keys", p.expr[start:p.pos])
+	}
+	return &selectStep{kind: selectIndex, index: idx}, nil
+}
+
+// Parses a filter, after the ?
+func (p *selectParser) parseFilter() (*selectStep, error) {
+	steps, err := p.parsePath(true)
+	if err != nil {
+		return nil, err
+	}
+	ret := &selectStep{kind: selectFilter, filterSteps: steps}
+
+	p.skipSpaces()
+	switch {
+	case strings.HasPrefix(p.expr[p.pos:], "=="):
+	case strings.HasPrefix(p.expr[p.pos:], "!="):
+		ret.filterNot = true
+	default:
+		return nil, fmt.Errorf("missing == or != operator at position %d", p.pos+1)
+	}
+	p.pos += 2
+	p.skipSpaces()
+
+	if p.peek() == '"' {
+		ret.filterValue, err = p.parseString()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		start := p.pos
+		for p.pos < len(p.expr) && p.expr[p.pos] != ']' && p.expr[p.pos] != ' ' {
+			p.pos++
+		}
+		ret.filterValue = p.expr[start:p.pos]
+		if ret.filterValue == "" {
+			return nil, fmt.Errorf("missing filter value at position %d", p.pos+1)
+		}
+	}
+	p.skipSpaces()
+
+	return ret, nil
+}
+
+// Parses a double quoted string, with Go escapes
+func (p *selectParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.expr) && p.expr[p.pos] != '"' {
+		if p.expr[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.expr) {
+		return "", fmt.Errorf("unterminated string at position %d", start+1)
+	}
+	p.pos++
+	return strconv.Unquote(p.expr[start:p.pos])
+}
+
+func (p *selectParser) skipSpaces() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+//
+// InvokeOutput - Select
+//
+// Outputs the values selected by a SelectPath from each response message, one per line. Values are output
+// raw as in SelectRawValue, or as JSON.
+//
+type SelectInvokeOutput struct {
+	Out  io.Writer
+	Path *SelectPath
+	JSON bool
+	// Encoding of selected bytes values in raw output
+	BytesEncoding BytesEncoding
+}
+
+func NewSelectInvokeOutput(out io.Writer, path *SelectPath) *SelectInvokeOutput {
+	return &SelectInvokeOutput{
+		Out:  out,
+		Path: path,
+	}
+}
+
+func (d *SelectInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return err
+	}
+
+	values, err := d.Path.Select(mv)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		var str string
+		if d.JSON {
+			b, err := json.Marshal(TemplateValue(v))
+			if err != nil {
+				return err
+			}
+			str = string(b)
+		} else if b, ok := selectBytesValue(v); ok {
+			str = d.BytesEncoding.Encode(b)
+		} else {
+			str = SelectRawValue(v)
+		}
+
+		_, err = fmt.Fprintln(d.Out, str)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func selectBytesValue(value interface{}) ([]byte, bool) {
+	if wv, ok := value.(*WellKnownValue); ok {
+		value = wv.Value
+	}
+	b, ok := value.([]byte)
+	return b, ok
+}
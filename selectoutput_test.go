@@ -0,0 +1,182 @@
+package grpcget
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSelectInvokeOutputEnumFilter(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	msg := testMessage(t, fd, "Items")
+	for _, it := range []struct {
+		name  string
+		state int32
+	}{{"a", 1}, {"b", 2}, {"c", 0}} {
+		item := testMessage(t, fd, "Item")
+		item.SetFieldByName("name", it.name)
+		item.SetFieldByName("state", it.state)
+		msg.AddRepeatedFieldByName("items", item)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: `items[?state=="ACTIVE"].name`, want: "a\n"},
+		{path: `items[?state=="active"].name`, want: "a\n"},
+		{path: `items[?state=="STATUS_ACTIVE"].name`, want: "a\n"},
+		{path: `items[?state=="NOT_ACTIVE"].name`, want: "b\n"},
+		{path: `items[?state=="1"].name`, want: "a\n"},
+		{path: `items[?state=="UNKNOWN"].name`, want: "c\n"},
+		{path: `items[?state!="ACTIVE"].name`, want: "b\nc\n"},
+		{path: `items[?state=="INVALID"].name`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParseSelectPath(tt.path)
+			if err != nil {
+				t.Fatalf("Error parsing path: %v", err)
+			}
+
+			var b bytes.Buffer
+			err = NewSelectInvokeOutput(&b, path).OutputInvoke(NewDynMsgHelper(), msg)
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectInvokeOutputAny(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper(WithDMHAnyResolver(&descriptorSourceAnyResolver{NewFileDescriptorSource(fd)}))
+
+	tests := []struct {
+		json    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{json: `{"v":{"@type":"type.googleapis.com/test.Inner","name":"n","num":2}}`, path: "v.name", want: "n\n"},
+		{json: `{"v":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"2s"}}`, path: "v.value", want: "2s\n"},
+		{json: `{"v":{"@type":"type.googleapis.com/google.protobuf.Struct","value":{"a":"b"}}}`, path: "v.value.a", want: "b\n"},
+		{json: `{"v":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"2s"}}`, path: "v.seconds", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			msg := testMessage(t, fd, "W_any")
+			if err := msg.UnmarshalJSON([]byte(tt.json)); err != nil {
+				t.Fatalf("Error setting value: %v", err)
+			}
+			path, err := ParseSelectPath(tt.path)
+			if err != nil {
+				t.Fatalf("Error parsing path: %v", err)
+			}
+
+			var b bytes.Buffer
+			err = NewSelectInvokeOutput(&b, path).OutputInvoke(dmh, msg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, output is %q", b.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectPath(t *testing.T) {
+	fd := testFileDescriptor(t)
+	msg := testRecord(t, fd)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: "name", want: "a \"b\"\n"},
+		{path: "inner", want: `{"name":"in","num":2}` + "\n"},
+		{path: "inner.num", want: "2\n"},
+		{path: "tags", want: `["a","b"]` + "\n"},
+		{path: "status", want: "STATUS_ACTIVE\n"},
+		{path: "created", want: "2020-01-02T03:04:05Z\n"},
+		// indexes
+		{path: "inners[0].name", want: "x\n"},
+		{path: "inners.1.name", want: "y\n"},
+		{path: "inners[-1].name", want: "y\n"},
+		{path: "inners[-2].name", want: "x\n"},
+		{path: "inners[2].name", want: ""},
+		{path: "inners[-3].name", want: ""},
+		{path: "tags[ 1 ]", want: "b\n"},
+		// all elements and map values
+		{path: "inners[*].name", want: "x\ny\n"},
+		{path: "inners.*.num", want: "1\n0\n"},
+		{path: "counts[*]", want: "1\n2\n"},
+		{path: "inner[*]", want: "in\n2\n"},
+		// map keys
+		{path: `counts["a"]`, want: "1\n"},
+		{path: "counts.b", want: "2\n"},
+		{path: `counts["z"]`, want: ""},
+		// filters
+		{path: `inners[?name=="x"].num`, want: "1\n"},
+		{path: `inners[?name!="x"].name`, want: "y\n"},
+		{path: `inners[?num==1].name`, want: "x\n"},
+		{path: `inners[?num == 0].name`, want: "y\n"},
+		{path: `inners[?name=="z"].name`, want: ""},
+		{path: `counts[?@==2]`, want: "2\n"},
+		{path: `tags[?@=="b"]`, want: "b\n"},
+		{path: `tags[?@!="b"]`, want: "a\n"},
+		// select errors
+		{path: "missing", wantErr: "Field missing not found in message test.Record"},
+		{path: "tags.x", wantErr: `Repeated values must be selected with an index or *, not "x"`},
+		{path: `tags["x"]`, wantErr: "Repeated values must be selected with an index or *"},
+		{path: "name.x", wantErr: "Values cannot be selected inside a scalar value"},
+		{path: "inner[0]", wantErr: "Fields of message test.Inner must be selected by name"},
+		// parse errors
+		{path: "", wantErr: `Invalid select path "": missing field name at position 1`},
+		{path: "inners..name", wantErr: `Invalid select path "inners..name": missing field name at position 8`},
+		{path: "inners[0", wantErr: `Invalid select path "inners[0": missing ] at position 9`},
+		{path: "inners[x]", wantErr: `Invalid select path "inners[x]": invalid index "x", use quotes for map keys`},
+		{path: `counts["a]`, wantErr: `Invalid select path "counts[\"a]": unterminated string at position 8`},
+		{path: "inners[?name]", wantErr: `Invalid select path "inners[?name]": missing == or != operator at position 13`},
+		{path: "inners[?name==]", wantErr: `Invalid select path "inners[?name==]": missing filter value at position 15`},
+		{path: "inners]", wantErr: `Invalid select path "inners]": unexpected ']' at position 7`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var b bytes.Buffer
+			path, err := ParseSelectPath(tt.path)
+			if err == nil {
+				err = NewSelectInvokeOutput(&b, path).OutputInvoke(NewDynMsgHelper(), msg)
+			}
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("Expected error %q, output is %q", tt.wantErr, b.String())
+				}
+				if err.Error() != tt.wantErr {
+					t.Errorf("Error is %q, want %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
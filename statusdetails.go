@@ -0,0 +1,75 @@
+package grpcget
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/status"
+
+	// register the google.rpc error details types
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// Decodes the details of the status, like google.rpc.BadRequest or google.rpc.ErrorInfo, as *dynamic.Message.
+// The types are resolved from the registered types, then from the descriptor source, which can be nil.
+// Details of unknown types are returned as the *any.Any.
+func DecodeStatusDetails(source DescriptorSource, st *status.Status) []proto.Message {
+	var ret []proto.Message
+	for _, detail := range st.Proto().GetDetails() {
+		value := &any.Any{
+			TypeUrl: detail.GetTypeUrl(),
+			Value:   detail.GetValue(),
+		}
+
+		md := findAnyMessageDescriptor(source, value.TypeUrl)
+		if md == nil {
+			ret = append(ret, value)
+			continue
+		}
+
+		msg := dynamic.NewMessage(md)
+		err := msg.Unmarshal(value.Value)
+		if err != nil {
+			ret = append(ret, value)
+			continue
+		}
+		ret = append(ret, msg)
+	}
+	return ret
+}
+
+// Returns the message type of an Any type URL, or nil if not found
+func findAnyMessageDescriptor(source DescriptorSource, typeURL string) *desc.MessageDescriptor {
+	name := typeURL[strings.LastIndex(typeURL, "/")+1:]
+
+	if md, err := desc.LoadMessageDescriptor(name); err == nil && md != nil {
+		return md
+	}
+
+	if source != nil {
+		if d, err := source.FindSymbol(name); err == nil {
+			if md, ok := d.(*desc.MessageDescriptor); ok {
+				return md
+			}
+		}
+	}
+
+	return nil
+}
+
+// Resolves Any types from the registered types, then from the descriptor source
+type descriptorSourceAnyResolver struct {
+	source DescriptorSource
+}
+
+func (r *descriptorSourceAnyResolver) ResolveAnyType(typeURL string) (*desc.MessageDescriptor, error) {
+	md := findAnyMessageDescriptor(r.source, typeURL)
+	if md == nil {
+		return nil, fmt.Errorf("Unknown message type %s", typeURL[strings.LastIndex(typeURL, "/")+1:])
+	}
+	return md, nil
+}
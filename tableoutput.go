@@ -0,0 +1,335 @@
+package grpcget
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//
+// InvokeOutput - Table
+//
+// Outputs the elements of a repeated message field of the response as the rows of an aligned text table or CSV.
+// The columns are the fields of the element message, with nested message fields flattened with dotted names, like
+// "inner.value". Repeated fields and maps are output in a single column, separated by commas.
+//
+// If Field is blank, the response must have a single repeated message field, or the response message itself
+// is output as a single row. Streamed messages are all output in the same table when the stream ends.
+//
+type TableInvokeOutput struct {
+	Out io.Writer
+	// Name of the repeated message field with the rows, blank to detect
+	Field string
+	// Columns to output, blank outputs all
+	Columns []string
+	// Columns to sort the rows by, prefix with "-" to sort descending
+	SortBy []string
+	// Output as CSV instead of an aligned text table
+	CSV bool
+
+	rows []*MessageValue
+}
+
+func NewTableInvokeOutput(out io.Writer) *TableInvokeOutput {
+	return &TableInvokeOutput{
+		Out: out,
+	}
+}
+
+func (d *TableInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	rows, err := d.messageRows(dmh, value)
+	if err != nil {
+		return err
+	}
+	return d.outputRows(rows)
+}
+
+func (d *TableInvokeOutput) OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error {
+	d.rows = nil
+	return nil
+}
+
+func (d *TableInvokeOutput) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	rows, err := d.messageRows(dmh, value)
+	if err != nil {
+		return err
+	}
+	d.rows = append(d.rows, rows...)
+	return nil
+}
+
+func (d *TableInvokeOutput) OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	rows := d.rows
+	d.rows = nil
+	return d.outputRows(rows)
+}
+
+// Returns the rows of the response message
+func (d *TableInvokeOutput) messageRows(dmh *DynMsgHelper, value proto.Message) ([]*MessageValue, error) {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	fld, err := d.rowsField(mv.Descriptor)
+	if err != nil {
+		return nil, err
+	}
+	if fld == nil {
+		// the message itself is the row
+		return []*MessageValue{mv}, nil
+	}
+
+	var rows []*MessageValue
+	if fv := mv.FieldByName(fld.GetName()); fv != nil {
+		items, ok := fv.Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Field %s cannot be output as a table", fld.GetName())
+		}
+		for _, item := range items {
+			row, ok := item.(*MessageValue)
+			if !ok {
+				return nil, fmt.Errorf("Field %s cannot be output as a table", fld.GetName())
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// Returns the repeated message field with the rows, or nil if the message itself is the row
+func (d *TableInvokeOutput) rowsField(md *desc.MessageDescriptor) (*desc.FieldDescriptor, error) {
+	if d.Field != "" {
+		fld := md.FindFieldByName(d.Field)
+		if fld == nil {
+			return nil, fmt.Errorf("Field %s not found in message %s", d.Field, md.GetFullyQualifiedName())
+		}
+		if !fld.IsRepeated() || fld.IsMap() || fld.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+			return nil, fmt.Errorf("Field %s is not a repeated message field", d.Field)
+		}
+		return fld, nil
+	}
+
+	var ret *desc.FieldDescriptor
+	for _, fld := range md.GetFields() {
+		if fld.IsRepeated() && !fld.IsMap() && fld.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+			if ret != nil {
+				return nil, fmt.Errorf("Message %s has more than one repeated message field, select the field to output", md.GetFullyQualifiedName())
+			}
+			ret = fld
+		}
+	}
+	return ret, nil
+}
+
+func (d *TableInvokeOutput) outputRows(rows []*MessageValue) error {
+	var columns []string
+	if len(d.Columns) > 0 {
+		columns = d.Columns
+	} else if len(rows) > 0 {
+		columns = tableColumns(rows[0].Descriptor, "", rows)
+	}
+
+	// flatten the rows
+	var values [][]interface{}
+	for _, row := range rows {
+		var rv []interface{}
+		for _, col := range columns {
+			rv = append(rv, tableCellValue(row, col))
+		}
+		values = append(values, rv)
+	}
+
+	err := d.sortRows(columns, values)
+	if err != nil {
+		return err
+	}
+
+	var cells [][]string
+	cells = append(cells, columns)
+	for _, rv := range values {
+		var row []string
+		for _, v := range rv {
+			row = append(row, tableFormatValue(v))
+		}
+		cells = append(cells, row)
+	}
+
+	if d.CSV {
+		w := csv.NewWriter(d.Out)
+		err = w.WriteAll(cells)
+		if err != nil {
+			return err
+		}
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(d.Out, 0, 0, 2, ' ', 0)
+	for _, row := range cells {
+		for ci, cell := range row {
+			// tabs would break the alignment
+			row[ci] = strings.Replace(cell, "\t", " ", -1)
+		}
+		_, err = fmt.Fprintln(w, strings.Join(row, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (d *TableInvokeOutput) sortRows(columns []string, values [][]interface{}) error {
+	type sortColumn struct {
+		index int
+		desc  bool
+	}
+	var sortColumns []sortColumn
+	for _, s := range d.SortBy {
+		sc := sortColumn{index: -1}
+		if strings.HasPrefix(s, "-") {
+			sc.desc = true
+			s = s[1:]
+		}
+		for ci, col := range columns {
+			if col == s {
+				sc.index = ci
+			}
+		}
+		if sc.index < 0 {
+			return fmt.Errorf("Sort column %s is not an output column", s)
+		}
+		sortColumns = append(sortColumns, sc)
+	}
+
+	if len(sortColumns) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		for _, sc := range sortColumns {
+			a, b := values[i][sc.index], values[j][sc.index]
+			if tableValueLess(a, b) {
+				return !sc.desc
+			}
+			if tableValueLess(b, a) {
+				return sc.desc
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// Returns the columns of the message, flattening the nested messages that are set in any of the rows
+func tableColumns(md *desc.MessageDescriptor, prefix string, rows []*MessageValue) []string {
+	var ret []string
+	for _, fld := range md.GetFields() {
+		name := prefix + fld.GetName()
+
+		// nested messages that are set in the rows, and not converted by a getter
+		var nested []*MessageValue
+		is_leaf := false
+		for _, row := range rows {
+			if fv := row.FieldByName(fld.GetName()); fv != nil {
+				if mv, ok := fv.Value.(*MessageValue); ok {
+					nested = append(nested, mv)
+				} else {
+					is_leaf = true
+				}
+			}
+		}
+
+		if len(nested) > 0 && !is_leaf {
+			ret = append(ret, tableColumns(fld.GetMessageType(), name+".", nested)...)
+		} else {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// Returns the value of the dotted column name in the row, the default value for unset scalar fields, or nil
+// if it is not a field or is inside an unset message
+func tableCellValue(row *MessageValue, column string) interface{} {
+	var value interface{} = row
+	for _, part := range strings.Split(column, ".") {
+		mv, ok := value.(*MessageValue)
+		if !ok {
+			return nil
+		}
+		if fv := mv.FieldByName(part); fv != nil {
+			value = fv.Value
+			continue
+		}
+		// proto3 fields with the default value are not set
+		if mv.Descriptor == nil {
+			return nil
+		}
+		fld := mv.Descriptor.FindFieldByName(part)
+		if fld == nil {
+			return nil
+		}
+		value = FieldDefaultValue(fld)
+	}
+	return value
+}
+
+func tableFormatValue(value interface{}) string {
+	switch xvalue := value.(type) {
+	case nil:
+		return ""
+	case *WellKnownValue:
+		switch xvalue.Value.(type) {
+		case []*MapEntryValue, []interface{}, *AnyValue:
+			// Struct, ListValue and Any as JSON
+			b, err := json.Marshal(TemplateValue(xvalue.Value))
+			if err != nil {
+				return err.Error()
+			}
+			return string(b)
+		}
+		return tableFormatValue(xvalue.Value)
+	case *MessageValue:
+		b, err := json.Marshal(TemplateValue(xvalue))
+		if err != nil {
+			return err.Error()
+		}
+		return string(b)
+	case []interface{}:
+		var items []string
+		for _, item := range xvalue {
+			items = append(items, tableFormatValue(item))
+		}
+		return strings.Join(items, ",")
+	case []*MapEntryValue:
+		var items []string
+		for _, entry := range xvalue {
+			items = append(items, fmt.Sprintf("%v=%s", entry.Key, tableFormatValue(entry.Value)))
+		}
+		return strings.Join(items, ",")
+	}
+	return formatScalarValue(value)
+}
+
+// Compares cell values, numerically if both are numbers. Unset values are sorted first.
+func tableValueLess(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	af, aok := numberValue(a)
+	bf, bok := numberValue(b)
+	if aok && bok {
+		return af < bf
+	}
+	return tableFormatValue(a) < tableFormatValue(b)
+}
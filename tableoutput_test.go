@@ -0,0 +1,149 @@
+package grpcget
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+func TestTableInvokeOutput(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	record := func(name string, ratio float64) *dynamic.Message {
+		msg := testMessage(t, fd, "Record")
+		msg.SetFieldByName("name", name)
+		msg.SetFieldByName("ratio", ratio)
+		return msg
+	}
+	rows := func(records ...*dynamic.Message) *dynamic.Message {
+		msg := testMessage(t, fd, "Rows")
+		for _, r := range records {
+			msg.AddRepeatedFieldByName("records", r)
+		}
+		return msg
+	}
+
+	quoted := record(`plain, "x"`, 10)
+	items := testMessage(t, fd, "Items")
+	for _, name := range []string{"b", "a"} {
+		item := testMessage(t, fd, "Item")
+		item.SetFieldByName("name", name)
+		items.AddRepeatedFieldByName("items", item)
+	}
+
+	tests := []struct {
+		name    string
+		msg     proto.Message
+		setup   func(o *TableInvokeOutput)
+		want    string
+		wantErr string
+	}{
+		{name: "all columns", msg: rows(testRecord(t, fd), quoted), setup: func(o *TableInvokeOutput) { o.Field = "records" },
+			want: "name        inner.name  inner.num  inners                                     tags  counts   status          data  ratio  created\n" +
+				"a \"b\"       in          2          {\"name\":\"x\",\"num\":1},{\"name\":\"y\",\"num\":0}  a,b   a=1,b=2  STATUS_ACTIVE   aGkA  0.5    2020-01-02T03:04:05Z\n" +
+				"plain, \"x\"                                                                                   STATUS_UNKNOWN        10     \n"},
+		{name: "csv quoting", msg: rows(testRecord(t, fd), quoted), setup: func(o *TableInvokeOutput) { o.Field = "records"; o.CSV = true },
+			want: "name,inner.name,inner.num,inners,tags,counts,status,data,ratio,created\n" +
+				"\"a \"\"b\"\"\",in,2,\"{\"\"name\"\":\"\"x\"\",\"\"num\"\":1},{\"\"name\"\":\"\"y\"\",\"\"num\"\":0}\",\"a,b\",\"a=1,b=2\",STATUS_ACTIVE,aGkA,0.5,2020-01-02T03:04:05Z\n" +
+				"\"plain, \"\"x\"\"\",,,,,,STATUS_UNKNOWN,,10,\n"},
+		{name: "columns", msg: rows(testRecord(t, fd), quoted),
+			setup: func(o *TableInvokeOutput) {
+				o.Field = "records"
+				o.CSV = true
+				o.Columns = []string{"inner.num", "name", "missing"}
+			},
+			want: "inner.num,name,missing\n2,\"a \"\"b\"\"\",\n,\"plain, \"\"x\"\"\",\n"},
+		{name: "sort numeric", msg: rows(record("a", 10), record("b", 9), record("c", 0.5)),
+			setup: func(o *TableInvokeOutput) {
+				o.Field = "records"
+				o.CSV = true
+				o.Columns = []string{"name", "ratio"}
+				o.SortBy = []string{"ratio"}
+			},
+			want: "name,ratio\nc,0.5\nb,9\na,10\n"},
+		{name: "sort descending", msg: rows(record("a", 1), record("c", 1), record("b", 2)),
+			setup: func(o *TableInvokeOutput) {
+				o.Field = "records"
+				o.CSV = true
+				o.Columns = []string{"name", "ratio"}
+				o.SortBy = []string{"-ratio", "-name"}
+			},
+			want: "name,ratio\nb,2\nc,1\na,1\n"},
+		{name: "sort unknown column", msg: rows(record("a", 1)),
+			setup: func(o *TableInvokeOutput) {
+				o.Field = "records"
+				o.Columns = []string{"name"}
+				o.SortBy = []string{"ratio"}
+			}, wantErr: "Sort column ratio is not an output column"},
+		{name: "detect repeated field", msg: items, setup: func(o *TableInvokeOutput) { o.CSV = true },
+			want: "name,state\nb,STATUS_UNKNOWN\na,STATUS_UNKNOWN\n"},
+		{name: "message as row", msg: testInner(t, fd, "n"), setup: func(o *TableInvokeOutput) { o.CSV = true },
+			want: "name,num\nn,0\n"},
+		{name: "no rows", msg: rows(), setup: func(o *TableInvokeOutput) { o.Field = "records"; o.CSV = true }, want: "\n"},
+		{name: "tabs in cells", msg: rows(record("a\tb", 1)), setup: func(o *TableInvokeOutput) { o.Field = "records"; o.Columns = []string{"name"} },
+			want: "name\na b\n"},
+		{name: "more than one repeated field", msg: rows(), wantErr: "Message test.Rows has more than one repeated message field, select the field to output"},
+		{name: "field not found", msg: rows(), setup: func(o *TableInvokeOutput) { o.Field = "missing" },
+			wantErr: "Field missing not found in message test.Rows"},
+		{name: "field not repeated message", msg: testRecord(t, fd), setup: func(o *TableInvokeOutput) { o.Field = "tags" },
+			wantErr: "Field tags is not a repeated message field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			output := NewTableInvokeOutput(&b)
+			if tt.setup != nil {
+				tt.setup(output)
+			}
+			err := output.OutputInvoke(NewDynMsgHelper(), tt.msg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Error is %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTableInvokeOutputStream(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper()
+
+	var b bytes.Buffer
+	output := NewTableInvokeOutput(&b)
+	output.CSV = true
+	err := output.OutputInvokeStreamBegin(dmh, nil)
+	if err == nil {
+		for _, name := range []string{"a", "b"} {
+			err = output.OutputInvokeStreamMessage(dmh, testInner(t, fd, name))
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Output before the end of the stream: %q", b.String())
+	}
+
+	err = output.OutputInvokeStreamEnd(dmh, nil, nil)
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+	// all streamed messages in the same table
+	if want := "name,num\na,0\nb,0\n"; b.String() != want {
+		t.Errorf("Output is %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,182 @@
+package grpcget
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//
+// InvokeOutput - Template
+//
+// Executes a text/template for each response message, followed by a new line. The template data is the
+// TemplateValue of the message, with the DynMsgHelper field getters applied.
+//
+type TemplateInvokeOutput struct {
+	Out      io.Writer
+	Template *template.Template
+}
+
+// Parses the template text, with the TemplateFuncs functions available
+func NewTemplateInvokeOutput(out io.Writer, text string) (*TemplateInvokeOutput, error) {
+	tmpl, err := template.New("output").Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing template: %v", err)
+	}
+
+	return &TemplateInvokeOutput{
+		Out:      out,
+		Template: tmpl,
+	}, nil
+}
+
+func (d *TemplateInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return err
+	}
+
+	err = d.Template.Execute(d.Out, TemplateValue(mv))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(d.Out)
+	return err
+}
+
+// Converts a MessageValue or one of its field values to the template data:
+//   - messages are map[string]interface{} keyed by the proto field name, with all fields of the message.
+//     Unset fields have their default value, with nil for messages.
+//   - repeated fields are []interface{}
+//   - maps are map[string]interface{}, with the keys formatted as strings
+//   - enums are the value name, or the number if it is not a value of the enum
+//   - bytes are base64 strings
+//   - well-known types are converted as in their JSON mapping, with Any as a map with the "@type" key
+func TemplateValue(value interface{}) interface{} {
+	switch xvalue := value.(type) {
+	case *MessageValue:
+		ret := map[string]interface{}{}
+		for _, fld := range xvalue.Descriptor.GetFields() {
+			ret[fld.GetName()] = TemplateValue(FieldDefaultValue(fld))
+		}
+		for _, f := range xvalue.Fields {
+			ret[f.Field.GetName()] = TemplateValue(f.Value)
+		}
+		return ret
+	case []*MapEntryValue:
+		ret := map[string]interface{}{}
+		for _, entry := range xvalue {
+			ret[fmt.Sprint(entry.Key)] = TemplateValue(entry.Value)
+		}
+		return ret
+	case []interface{}:
+		ret := []interface{}{}
+		for _, item := range xvalue {
+			ret = append(ret, TemplateValue(item))
+		}
+		return ret
+	case *WellKnownValue:
+		return TemplateValue(xvalue.Value)
+	case *AnyValue:
+		if xvalue.Message == nil {
+			return map[string]interface{}{"@type": xvalue.TypeURL, "value": TemplateValue(xvalue.Raw)}
+		}
+		if xvalue.WellKnown != nil {
+			return map[string]interface{}{"@type": xvalue.TypeURL, "value": TemplateValue(xvalue.WellKnown)}
+		}
+		ret := TemplateValue(xvalue.Message).(map[string]interface{})
+		ret["@type"] = xvalue.TypeURL
+		return ret
+	case EnumValue:
+		if xvalue.Name != "" {
+			return xvalue.Name
+		}
+		return xvalue.Number
+	case []byte:
+		return base64.StdEncoding.EncodeToString(xvalue)
+	}
+	return value
+}
+
+// Functions available to the templates:
+//   - json VALUE: encodes the value as JSON
+//   - join LIST SEP: joins the elements of a repeated field with the separator
+//   - timestamp VALUE: converts a google.protobuf.Timestamp message, a RFC3339 string or unix seconds to a time.Time
+//   - formatTime LAYOUT VALUE: formats a timestamp with a time.Format layout
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json":       templateJSON,
+		"join":       templateJoin,
+		"timestamp":  templateTimestamp,
+		"formatTime": templateFormatTime,
+	}
+}
+
+func templateJSON(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func templateJoin(list interface{}, sep string) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("Cannot join value of type %T", list)
+	}
+	var items []string
+	for i := 0; i < rv.Len(); i++ {
+		items = append(items, fmt.Sprint(rv.Index(i).Interface()))
+	}
+	return strings.Join(items, sep), nil
+}
+
+func templateTimestamp(value interface{}) (time.Time, error) {
+	switch xvalue := value.(type) {
+	case time.Time:
+		return xvalue, nil
+	case map[string]interface{}:
+		// google.protobuf.Timestamp message
+		var seconds, nanos int64
+		if s, ok := xvalue["seconds"].(int64); ok {
+			seconds = s
+		}
+		if n, ok := xvalue["nanos"].(int32); ok {
+			nanos = int64(n)
+		}
+		return time.Unix(seconds, nanos).UTC(), nil
+	case string:
+		return time.Parse(time.RFC3339Nano, xvalue)
+	case int64:
+		return time.Unix(xvalue, 0).UTC(), nil
+	case int32:
+		return time.Unix(int64(xvalue), 0).UTC(), nil
+	case uint64:
+		return time.Unix(int64(xvalue), 0).UTC(), nil
+	case uint32:
+		return time.Unix(int64(xvalue), 0).UTC(), nil
+	case nil:
+		return time.Time{}, nil
+	}
+	return time.Time{}, fmt.Errorf("Cannot convert value of type %T to a timestamp", value)
+}
+
+func templateFormatTime(layout string, value interface{}) (string, error) {
+	t, err := templateTimestamp(value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
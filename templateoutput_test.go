@@ -0,0 +1,85 @@
+package grpcget
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+func TestTemplateInvokeOutput(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	tests := []struct {
+		name     string
+		template string
+		msg      *dynamic.Message
+		dmh      *DynMsgHelper
+		want     string
+		wantErr  string
+	}{
+		{name: "field", template: `{{.name}}`, want: `a "b"`},
+		{name: "nested", template: `{{.inner.name}}/{{.inner.num}}`, want: "in/2"},
+		{name: "repeated message", template: `{{len .inners}} {{(index .inners 1).name}} {{(index .inners 1).num}}`, want: "2 y 0"},
+		{name: "range", template: `{{range .inners}}[{{.name}}]{{end}}`, want: "[x][y]"},
+		{name: "map", template: `{{.counts.a}} {{index .counts "b"}}`, want: "1 2"},
+		{name: "enum", template: `{{.status}}`, want: "STATUS_ACTIVE"},
+		{name: "bytes", template: `{{.data}}`, want: "aGkA"},
+		{name: "float", template: `{{.ratio}}`, want: "0.5"},
+		{name: "timestamp", template: `{{.created}}`, want: "2020-01-02T03:04:05Z"},
+		{name: "json message", template: `{{json .inner}}`, want: `{"name":"in","num":2}`},
+		{name: "json map", template: `{{json .counts}}`, want: `{"a":1,"b":2}`},
+		{name: "json string", template: `{{json .name}}`, want: `"a \"b\""`},
+		{name: "json list", template: `{{json .tags}}`, want: `["a","b"]`},
+		{name: "join", template: `{{join .tags ", "}}`, want: "a, b"},
+		{name: "join empty", template: `[{{join .tags ","}}]`, msg: testMessage(t, fd, "Record"), want: "[]"},
+		{name: "join not a list", template: `{{join .name ","}}`, wantErr: "Cannot join value of type string"},
+		{name: "formatTime", template: `{{formatTime "2006-01-02 15:04" .created}}`, want: "2020-01-02 03:04"},
+		{name: "formatTime unix seconds", template: `{{formatTime "2006" .inner.num}}`, want: "1970"},
+		{name: "formatTime invalid", template: `{{formatTime "2006" .status}}`, wantErr: `parsing time "STATUS_ACTIVE"`},
+		{name: "timestamp", template: `{{(timestamp .created).Unix}}`, want: "1577934245"},
+		{name: "unset defaults", template: `[{{.name}}] {{.status}} {{.inner}} {{len .tags}} {{.ratio}}`, msg: testMessage(t, fd, "Record"),
+			want: "[] STATUS_UNKNOWN <no value> 0 0"},
+		{name: "field getter", template: `{{.inner}}`, dmh: NewDynMsgHelper(WithDMHFieldValueGetters(&testInnerGetter{})), want: "in/2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg
+			if msg == nil {
+				msg = testRecord(t, fd)
+			}
+			dmh := tt.dmh
+			if dmh == nil {
+				dmh = NewDynMsgHelper()
+			}
+
+			var b bytes.Buffer
+			output, err := NewTemplateInvokeOutput(&b, tt.template)
+			if err != nil {
+				t.Fatalf("Error parsing template: %v", err)
+			}
+			err = output.OutputInvoke(dmh, msg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error is %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want+"\n" {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want+"\n")
+			}
+		})
+	}
+}
+
+func TestTemplateInvokeOutputParseError(t *testing.T) {
+	_, err := NewTemplateInvokeOutput(&bytes.Buffer{}, "{{.name")
+	if err == nil || !strings.HasPrefix(err.Error(), "Error parsing template: ") {
+		t.Errorf("Error is %v, want a template parse error", err)
+	}
+}
@@ -0,0 +1,172 @@
+package grpcget
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+//
+// TextInvokeParamSetter
+//
+// Params are a message in the protobuf text format, merged into the request message, so fields not in the
+// message keep the values set by previous setters. Parse errors include the line, column and field path.
+//
+type TextInvokeParamSetter struct {
+	Data []byte
+}
+
+func NewTextInvokeParamSetter(data []byte) *TextInvokeParamSetter {
+	return &TextInvokeParamSetter{
+		Data: data,
+	}
+}
+
+func (i *TextInvokeParamSetter) SetInvokeParam(dmh *DynMsgHelper, req *dynamic.Message) error {
+	err := req.UnmarshalMergeText(i.Data)
+	if err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("Error parsing text request: unexpected end of input, missing }")
+	}
+	if err != nil {
+		return textInputError(i.Data, err)
+	}
+
+	return nil
+}
+
+var textErrorPosition = regexp.MustCompile(`^line (\d+), col (\d+): (.*)$`)
+
+// Adds the field path to text format errors with a position
+func textInputError(data []byte, err error) error {
+	m := textErrorPosition.FindStringSubmatch(err.Error())
+	if m == nil {
+		return fmt.Errorf("Error parsing text request: %v", err)
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	col, _ := strconv.Atoi(m[2])
+	if path := textFieldPathAt(data, line, col); path != "" {
+		return fmt.Errorf("Error parsing text request at line %d, column %d, field %s: %s", line, col, path, m[3])
+	}
+	return fmt.Errorf("Error parsing text request at line %d, column %d: %s", line, col, m[3])
+}
+
+// Returns the dotted path of the field at a position of a text format message, by following the field names
+// and braces up to it
+func textFieldPathAt(data []byte, line, col int) string {
+	var path []string
+	// '{' for messages and '[' for lists
+	var scopes []byte
+	var current string
+	var last byte
+
+	text := []rune(string(data))
+	pos, tline, tcol := 0, 1, 1
+	advance := func() {
+		if text[pos] == '\n' {
+			tline, tcol = tline+1, 1
+		} else {
+			tcol++
+		}
+		pos++
+	}
+	isIdent := func(c rune) bool {
+		return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+
+	for pos < len(text) {
+		c := text[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			advance()
+			continue
+		case c == '#':
+			for pos < len(text) && text[pos] != '\n' {
+				advance()
+			}
+			continue
+		}
+
+		// stop after the token at the position
+		if tline > line || tline == line && tcol > col {
+			break
+		}
+
+		in_list := len(scopes) > 0 && scopes[len(scopes)-1] == '['
+		is_key := last != ':' && last != '-' && !in_list
+
+		switch {
+		case c == '"' || c == '\'':
+			advance()
+			for pos < len(text) && text[pos] != c && text[pos] != '\n' {
+				if text[pos] == '\\' {
+					advance()
+				}
+				if pos < len(text) {
+					advance()
+				}
+			}
+			if pos < len(text) {
+				advance()
+			}
+			last = '"'
+		case c == '[' && is_key:
+			// extension or Any type name
+			start := pos
+			for pos < len(text) && text[pos] != ']' {
+				advance()
+			}
+			if pos < len(text) {
+				advance()
+			}
+			current = string(text[start:pos])
+			last = 'a'
+		case isIdent(c):
+			start := pos
+			for pos < len(text) && (isIdent(text[pos]) || text[pos] == '+' || text[pos] == '-') {
+				advance()
+			}
+			if is_key {
+				current = string(text[start:pos])
+			}
+			last = 'a'
+		case c == '{' || c == '<':
+			path = append(path, current)
+			scopes = append(scopes, '{')
+			current = ""
+			advance()
+			last = '{'
+		case c == '}' || c == '>':
+			if len(scopes) > 0 && len(path) > 0 {
+				current = path[len(path)-1]
+				path = path[:len(path)-1]
+				scopes = scopes[:len(scopes)-1]
+			}
+			advance()
+			last = '}'
+		case c == '[':
+			scopes = append(scopes, '[')
+			advance()
+			last = '['
+		case c == ']':
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			advance()
+			last = ']'
+		default:
+			// ':', '-', ',' and ';'
+			last = byte(c)
+			advance()
+		}
+	}
+
+	if current != "" {
+		path = append(path, current)
+	}
+	return strings.Join(path, ".")
+}
@@ -0,0 +1,35 @@
+package grpcget
+
+import "testing"
+
+func TestTextFieldPathAt(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+		col  int
+		want string
+	}{
+		{name: "field", data: `name: "x"`, line: 1, col: 7, want: "name"},
+		{name: "field name", data: "name: x", line: 1, col: 1, want: "name"},
+		{name: "nested", data: "inner {\n  value: 1\n}", line: 2, col: 10, want: "inner.value"},
+		{name: "after nested", data: "inner {\n  value: 1\n}\nname: 2", line: 4, col: 7, want: "name"},
+		{name: "deep nested", data: "inner: {\n  deep { value: 1 }\n}", line: 2, col: 17, want: "inner.deep.value"},
+		{name: "angle brackets", data: "inner <\n  value: 1\n>", line: 2, col: 10, want: "inner.value"},
+		{name: "list of messages", data: `items: [ { name: "a" }, { state: X } ]`, line: 1, col: 34, want: "items.state"},
+		{name: "list of scalars", data: "tags: [a, b, c]", line: 1, col: 14, want: "tags"},
+		{name: "extension", data: "[ext.name]: 1", line: 1, col: 13, want: "[ext.name]"},
+		{name: "nested extension", data: "inner {\n  [pkg.ext] { value: 1 }\n}", line: 2, col: 23, want: "inner.[pkg.ext].value"},
+		{name: "comment", data: "inner {\n  # other { value: 1 }\n  value: 2\n}", line: 3, col: 10, want: "inner.value"},
+		{name: "braces in string", data: `name: "a { b" value: 1`, line: 1, col: 22, want: "value"},
+		{name: "escaped quote in string", data: `name: 'it\'s {' value: 1`, line: 1, col: 24, want: "value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := textFieldPathAt([]byte(tt.data), tt.line, tt.col); got != tt.want {
+				t.Errorf("Path at %d:%d is %q, want %q", tt.line, tt.col, got, tt.want)
+			}
+		})
+	}
+}
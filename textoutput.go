@@ -0,0 +1,202 @@
+package grpcget
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//
+// InvokeOutput - Protobuf text format
+//
+// Outputs the response in the protobuf text format. Fields converted by DynMsgHelper field getters are output
+// as strings.
+//
+type TextInvokeOutput struct {
+	Out             io.Writer
+	Indent          string
+	StreamSeparator string
+
+	streamCount int
+}
+
+func NewTextInvokeOutput(out io.Writer) *TextInvokeOutput {
+	return &TextInvokeOutput{
+		Out:             out,
+		Indent:          "  ",
+		StreamSeparator: "---",
+	}
+}
+
+func (d *TextInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return err
+	}
+
+	return d.DumpMessage(0, mv)
+}
+
+func (d *TextInvokeOutput) OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error {
+	d.streamCount = 0
+	return nil
+}
+
+func (d *TextInvokeOutput) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	// separate streamed messages
+	if d.streamCount > 0 {
+		_, err := fmt.Fprintln(d.Out, d.StreamSeparator)
+		if err != nil {
+			return err
+		}
+	}
+	d.streamCount++
+
+	return d.OutputInvoke(dmh, value)
+}
+
+func (d *TextInvokeOutput) OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	return nil
+}
+
+func (d *TextInvokeOutput) DumpMessage(level int, msg *MessageValue) error {
+	for _, f := range msg.Fields {
+		name := f.Field.GetName()
+		if f.Field.IsExtension() {
+			name = "[" + f.Field.GetFullyQualifiedName() + "]"
+		}
+
+		var err error
+		switch xvalue := f.Value.(type) {
+		case []*MapEntryValue:
+			for _, entry := range xvalue {
+				err = d.DumpField(level, name, &MessageValue{
+					Fields: []*MessageFieldValue{
+						{Field: f.Field.GetMapKeyType(), Value: entry.Key},
+						{Field: f.Field.GetMapValueType(), Value: entry.Value},
+					},
+				})
+				if err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, item := range xvalue {
+				err = d.DumpField(level, name, item)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			err = d.DumpField(level, name, xvalue)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *TextInvokeOutput) DumpField(level int, name string, value interface{}) error {
+	levelStr := strings.Repeat(d.Indent, level)
+
+	// well-known types are output in the message form, with the expanded syntax for resolved Any messages
+	if wv, ok := value.(*WellKnownValue); ok {
+		if av, ok := wv.Value.(*AnyValue); ok && av.Message != nil {
+			_, err := fmt.Fprintf(d.Out, "%s%s {\n", levelStr, name)
+			if err != nil {
+				return err
+			}
+			err = d.DumpField(level+1, "["+av.TypeURL+"]", av.Message)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(d.Out, "%s}\n", levelStr)
+			return err
+		}
+		value = wv.Message
+	}
+
+	if mv, ok := value.(*MessageValue); ok {
+		_, err := fmt.Fprintf(d.Out, "%s%s {\n", levelStr, name)
+		if err != nil {
+			return err
+		}
+		err = d.DumpMessage(level+1, mv)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(d.Out, "%s}\n", levelStr)
+		return err
+	}
+
+	_, err := fmt.Fprintf(d.Out, "%s%s: %s\n", levelStr, name, textFormatScalar(value))
+	return err
+}
+
+// Formats a scalar value in the protobuf text format
+func textFormatScalar(value interface{}) string {
+	switch xvalue := value.(type) {
+	case string:
+		return textFormatQuote([]byte(xvalue))
+	case []byte:
+		return textFormatQuote(xvalue)
+	case EnumValue:
+		return xvalue.String()
+	case float32:
+		return textFormatFloat(float64(xvalue), 32)
+	case float64:
+		return textFormatFloat(xvalue, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func textFormatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// Quotes a string using the protobuf text format escapes, non-printable bytes are escaped in octal
+func textFormatQuote(b []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range b {
+		switch c {
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\\':
+			sb.WriteString(`\\`)
+		default:
+			if c >= 0x20 && c < 0x7f {
+				sb.WriteByte(c)
+			} else {
+				fmt.Fprintf(&sb, "\\%03o", c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
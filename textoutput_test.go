@@ -0,0 +1,93 @@
+package grpcget
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+func TestTextInvokeOutput(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	scalar := func(name string, value interface{}) *dynamic.Message {
+		msg := testMessage(t, fd, name)
+		msg.SetFieldByName("v", value)
+		return msg
+	}
+
+	tests := []struct {
+		name string
+		msg  *dynamic.Message
+		dmh  *DynMsgHelper
+		want string
+	}{
+		{name: "record", msg: testRecord(t, fd),
+			want: "name: \"a \\\"b\\\"\"\ninner {\n  name: \"in\"\n  num: 2\n}\n" +
+				"inners {\n  name: \"x\"\n  num: 1\n}\ninners {\n  name: \"y\"\n}\n" +
+				"tags: \"a\"\ntags: \"b\"\n" +
+				"counts {\n  key: \"a\"\n  value: 1\n}\ncounts {\n  key: \"b\"\n  value: 2\n}\n" +
+				"status: STATUS_ACTIVE\ndata: \"hi\\000\"\nratio: 0.5\ncreated {\n  seconds: 1577934245\n}\n"},
+		{name: "field getter", msg: testRecord(t, fd), dmh: NewDynMsgHelper(WithDMHFieldValueGetters(&testInnerGetter{})),
+			want: "name: \"a \\\"b\\\"\"\ninner: \"in/2\"\n" +
+				"inners {\n  name: \"x\"\n  num: 1\n}\ninners {\n  name: \"y\"\n}\n" +
+				"tags: \"a\"\ntags: \"b\"\n" +
+				"counts {\n  key: \"a\"\n  value: 1\n}\ncounts {\n  key: \"b\"\n  value: 2\n}\n" +
+				"status: STATUS_ACTIVE\ndata: \"hi\\000\"\nratio: 0.5\ncreated {\n  seconds: 1577934245\n}\n"},
+		{name: "string escapes", msg: scalar("S_string", "a\n\t'\\é"), want: "v: \"a\\n\\t\\'\\\\\\303\\251\"\n"},
+		{name: "unknown enum number", msg: scalar("E_enum", int32(7)), want: "v: 7\n"},
+		{name: "float inf", msg: scalar("S_float", float32(math.Inf(1))), want: "v: inf\n"},
+		{name: "double -inf", msg: scalar("S_double", math.Inf(-1)), want: "v: -inf\n"},
+		{name: "double nan", msg: scalar("S_double", math.NaN()), want: "v: nan\n"},
+		{name: "map message", msg: scalar("M_string_message", map[interface{}]interface{}{"k": testInner(t, fd, "n")}),
+			want: "v {\n  key: \"k\"\n  value {\n    name: \"n\"\n  }\n}\n"},
+		{name: "empty", msg: testMessage(t, fd, "Record"), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dmh := tt.dmh
+			if dmh == nil {
+				dmh = NewDynMsgHelper()
+			}
+
+			var b bytes.Buffer
+			err := NewTextInvokeOutput(&b).OutputInvoke(dmh, tt.msg)
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTextInvokeOutputStream(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper()
+
+	var b bytes.Buffer
+	output := NewTextInvokeOutput(&b)
+	err := output.OutputInvokeStreamBegin(dmh, nil)
+	if err == nil {
+		for _, name := range []string{"a", "b"} {
+			err = output.OutputInvokeStreamMessage(dmh, testInner(t, fd, name))
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = output.OutputInvokeStreamEnd(dmh, nil, nil)
+	}
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+
+	// separated, without a separator after the last message
+	if want := "name: \"a\"\n---\nname: \"b\"\n"; b.String() != want {
+		t.Errorf("Output is %q, want %q", b.String(), want)
+	}
+}
@@ -2,22 +2,80 @@ package grpcget
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/RangelReale/grpcget/bytesvalue"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
 	"google.golang.org/grpc/metadata"
 )
 
 // Parse a name=value argument into separate variables
 func ParseArgumentParam(argument string) (name string, value string, err error) {
-	args := strings.Split(argument, "=")
+	// only the first = separates the name, values can have it, like base64 padding
+	args := strings.SplitN(argument, "=", 2)
 	if len(args) != 2 {
-		return "", "", fmt.Errorf("Invoke param must have 2 values, have %d", len(args))
+		return "", "", fmt.Errorf("Invoke param must be in the format name=value")
 	}
 
 	return args[0], args[1], nil
 }
 
+// Split a line into arguments separated by spaces. Arguments can be quoted with single or double quotes,
+// and backslash escapes the next character outside single quotes.
+func SplitArguments(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	has_arg, escape := false, false
+
+	for _, c := range line {
+		switch {
+		case escape:
+			cur.WriteRune(c)
+			escape = false
+		case c == '\\' && quote != '\'':
+			escape = true
+			has_arg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			has_arg = true
+		case c == ' ' || c == '\t':
+			if has_arg {
+				args = append(args, cur.String())
+				cur.Reset()
+				has_arg = false
+			}
+		default:
+			cur.WriteRune(c)
+			has_arg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated quote in '%s'", line)
+	}
+	if escape {
+		return nil, fmt.Errorf("Unterminated escape in '%s'", line)
+	}
+	if has_arg {
+		args = append(args, cur.String())
+	}
+
+	return args, nil
+}
+
 // MetadataFromHeaders converts a list of header strings (each string in
 // "Header-Name=Header-Value" form) into metadata. If a string has a header
 // name without a value (e.g. does not contain a colon), the value is assumed
@@ -35,8 +93,8 @@ func MetadataFromHeaders(headers []string) metadata.MD {
 			headerName := strings.ToLower(strings.TrimSpace(pieces[0]))
 			val := strings.TrimSpace(pieces[1])
 			if strings.HasSuffix(headerName, "-bin") {
-				if v, err := decode(val); err == nil {
-					val = v
+				if v, err := grpcget_bytesvalue.DecodeBase64(val); err == nil {
+					val = string(v)
 				}
 			}
 			md[headerName] = append(md[headerName], val)
@@ -45,22 +103,82 @@ func MetadataFromHeaders(headers []string) metadata.MD {
 	return md
 }
 
-var base64Codecs = []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding}
-
-func decode(val string) (string, error) {
-	var firstErr error
-	var b []byte
-	// we are lenient and can accept any of the flavors of base64 encoding
-	for _, d := range base64Codecs {
-		var err error
-		b, err = d.DecodeString(val)
-		if err != nil {
-			if firstErr == nil {
-				firstErr = err
+// Returns the field type name as used in .proto files, like "string" or "message"
+func fieldTypeName(fld *desc.FieldDescriptor) string {
+	if fld.IsMap() {
+		return "map"
+	}
+	return strings.ToLower(strings.TrimPrefix(fld.GetType().String(), "TYPE_"))
+}
+
+// Returns the fully qualified name of the message or enum type of the field, or blank for scalar types
+func fieldTypeRefName(fld *desc.FieldDescriptor) string {
+	switch fld.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE, descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return fld.GetMessageType().GetFullyQualifiedName()
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return fld.GetEnumType().GetFullyQualifiedName()
+	}
+	return ""
+}
+
+// Returns the field label name as used in .proto files, like "optional" or "repeated"
+func fieldLabelName(fld *desc.FieldDescriptor) string {
+	return strings.ToLower(strings.TrimPrefix(fld.GetLabel().String(), "LABEL_"))
+}
+
+// Encoding of bytes values in outputs
+type BytesEncoding int
+
+const (
+	BytesBase64 BytesEncoding = iota
+	BytesHex
+	// Printable UTF-8 text as is, or with the "text:" prefix if it starts with an encoding prefix, and other
+	// values as base64 with the "base64:" prefix, so they can be parsed back with ParseBytesValue
+	BytesText
+)
+
+func (e BytesEncoding) Encode(b []byte) string {
+	switch e {
+	case BytesHex:
+		return hex.EncodeToString(b)
+	case BytesText:
+		if isPrintableText(b) {
+			for _, prefix := range grpcget_bytesvalue.Prefixes {
+				if strings.HasPrefix(string(b), prefix) {
+					return "text:" + string(b)
+				}
 			}
-			continue
+			return string(b)
 		}
-		return string(b), nil
+		return "base64:" + base64.StdEncoding.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func isPrintableText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, c := range string(b) {
+		if !unicode.IsPrint(c) && !unicode.IsSpace(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parses a bytes value, with the encoding prefixes of grpcget_bytesvalue.Parse
+func ParseBytesValue(value string) ([]byte, error) {
+	return grpcget_bytesvalue.Parse(value)
+}
+
+// Returns the metadata keys in sorted order
+func sortedMetadataKeys(md metadata.MD) []string {
+	var keys []string
+	for k := range md {
+		keys = append(keys, k)
 	}
-	return "", firstErr
+	sort.Strings(keys)
+	return keys
 }
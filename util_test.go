@@ -0,0 +1,77 @@
+package grpcget
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+func TestBytesTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  string
+	}{
+		{name: "text", value: []byte("hello world"), want: "hello world"},
+		{name: "text with newline", value: []byte("a\nb"), want: "a\nb"},
+		{name: "unicode", value: []byte("olá"), want: "olá"},
+		{name: "empty", value: []byte{}, want: ""},
+		{name: "binary", value: []byte("hi\x00"), want: "base64:aGkA"},
+		{name: "invalid utf8", value: []byte{0xff, 0xfe}, want: "base64://4="},
+		{name: "text with prefix", value: []byte("hex:0a"), want: "text:hex:0a"},
+		{name: "text with text prefix", value: []byte("text:a"), want: "text:text:a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := BytesText.Encode(tt.value)
+			if text != tt.want {
+				t.Errorf("Encoded value is %q, want %q", text, tt.want)
+			}
+			got, err := ParseBytesValue(text)
+			if err != nil {
+				t.Fatalf("Error parsing value: %v", err)
+			}
+			if !bytes.Equal(got, tt.value) {
+				t.Errorf("Parsed value is %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+type testBytesParser struct{}
+
+func (p *testBytesParser) ParseFieldValue(fld *desc.FieldDescriptor, value string) (ok bool, retval interface{}, err error) {
+	if value == "custom" {
+		return true, []byte("parsed"), nil
+	}
+	return false, nil, nil
+}
+
+func TestParseFieldParamValueBytesParser(t *testing.T) {
+	fd := testFileDescriptor(t)
+	fld := fd.FindMessage("test.S_bytes").FindFieldByName("v")
+	dmh := NewDynMsgHelper(WithDMHFieldValueParsers(&testBytesParser{}))
+
+	tests := []struct {
+		value string
+		want  []byte
+	}{
+		{value: "custom", want: []byte("parsed")},
+		{value: "hex:0a", want: []byte{0x0a}},
+		{value: "plain", want: []byte("plain")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := dmh.ParseFieldParamValue(fld, tt.value)
+			if err != nil {
+				t.Fatalf("Error parsing value: %v", err)
+			}
+			if !bytes.Equal(got.([]byte), tt.want) {
+				t.Errorf("Value is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
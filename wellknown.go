@@ -0,0 +1,241 @@
+package grpcget
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+//
+// Well-known types
+//
+// Values of the google.protobuf well-known types, in the same representation as their canonical JSON mapping.
+//
+
+// Value of a well-known type message
+type WellKnownValue struct {
+	// The message itself, for outputs that use the message form
+	Message *MessageValue
+	// The value of the message:
+	//   - Timestamp: RFC3339 string
+	//   - Duration: string in seconds with the "s" suffix, like "1.5s"
+	//   - FieldMask: comma separated paths in lowerCamelCase, like "a.fieldName,b"
+	//   - wrappers: the scalar value
+	//   - Struct: []*MapEntryValue sorted by key
+	//   - ListValue: []interface{}
+	//   - Value: nil, float64, string, bool, []*MapEntryValue or []interface{}
+	//   - Any: *AnyValue
+	Value interface{}
+}
+
+// Value of a google.protobuf.Any message
+type AnyValue struct {
+	TypeURL string
+	// The packed message, nil if the type could not be resolved
+	Message *MessageValue
+	// The value of the packed message if it is a well-known type, which in the JSON mapping is output in the
+	// "value" field
+	WellKnown *WellKnownValue
+	// The packed message bytes, if the type could not be resolved
+	Raw []byte
+}
+
+// Returns the value of a well-known type message, as described in WellKnownValue.Value, or ok false if the
+// message is not a supported well-known type. Any messages are resolved with the DynMsgHelperAnyResolver, or
+// the registered types if none was set.
+func (h *DynMsgHelper) WellKnownTypeValue(msg proto.Message) (ok bool, value interface{}, err error) {
+	dmsg, err := asDynamicMessage(msg)
+	if err != nil {
+		return false, nil, err
+	}
+
+	switch dmsg.GetMessageDescriptor().GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp":
+		seconds, nanos := dmsg.GetFieldByName("seconds").(int64), dmsg.GetFieldByName("nanos").(int32)
+		return true, time.Unix(seconds, int64(nanos)).UTC().Format(time.RFC3339Nano), nil
+	case "google.protobuf.Duration":
+		seconds, nanos := dmsg.GetFieldByName("seconds").(int64), dmsg.GetFieldByName("nanos").(int32)
+		return true, formatDuration(seconds, nanos), nil
+	case "google.protobuf.FieldMask":
+		var paths []string
+		for _, p := range dmsg.GetFieldByName("paths").([]interface{}) {
+			paths = append(paths, fieldMaskPath(p.(string)))
+		}
+		return true, strings.Join(paths, ","), nil
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue", "google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value", "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return true, dmsg.GetFieldByName("value"), nil
+	case "google.protobuf.Struct":
+		value, err := structValue(dmsg)
+		return err == nil, value, err
+	case "google.protobuf.ListValue":
+		value, err := listValue(dmsg)
+		return err == nil, value, err
+	case "google.protobuf.Value":
+		value, err := valueValue(dmsg)
+		return err == nil, value, err
+	case "google.protobuf.Any":
+		value, err := h.anyValue(dmsg)
+		return err == nil, value, err
+	}
+
+	return false, nil, nil
+}
+
+// Returns the message type of the type URL of an Any message
+func (h *DynMsgHelper) FindAnyMessageDescriptor(typeURL string) (*desc.MessageDescriptor, error) {
+	if h.opts.anyResolver != nil {
+		return h.opts.anyResolver.ResolveAnyType(typeURL)
+	}
+
+	name := typeURL[strings.LastIndex(typeURL, "/")+1:]
+	md, err := desc.LoadMessageDescriptor(name)
+	if err != nil {
+		return nil, err
+	}
+	if md == nil {
+		return nil, fmt.Errorf("Unknown message type %s", name)
+	}
+	return md, nil
+}
+
+func (h *DynMsgHelper) anyValue(dmsg *dynamic.Message) (*AnyValue, error) {
+	ret := &AnyValue{
+		TypeURL: dmsg.GetFieldByName("type_url").(string),
+		Raw:     dmsg.GetFieldByName("value").([]byte),
+	}
+
+	md, err := h.FindAnyMessageDescriptor(ret.TypeURL)
+	if err != nil {
+		// output the raw bytes
+		return ret, nil
+	}
+
+	packed := dynamic.NewMessage(md)
+	err = packed.Unmarshal(ret.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding Any of type %s: %v", ret.TypeURL, err)
+	}
+
+	ret.Message, err = h.MessageValue(packed)
+	if err != nil {
+		return nil, err
+	}
+	is_wkt, wv, err := h.WellKnownTypeValue(packed)
+	if err != nil {
+		return nil, err
+	}
+	if is_wkt {
+		ret.WellKnown = &WellKnownValue{Message: ret.Message, Value: wv}
+	}
+	ret.Raw = nil
+	return ret, nil
+}
+
+func structValue(dmsg *dynamic.Message) ([]*MapEntryValue, error) {
+	ret := []*MapEntryValue{}
+	for k, v := range dmsg.GetFieldByName("fields").(map[interface{}]interface{}) {
+		vmsg, err := asDynamicMessage(v.(proto.Message))
+		if err != nil {
+			return nil, err
+		}
+		value, err := valueValue(vmsg)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, &MapEntryValue{Key: k, Value: value})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return mapKeyLess(ret[i].Key, ret[j].Key)
+	})
+	return ret, nil
+}
+
+func listValue(dmsg *dynamic.Message) ([]interface{}, error) {
+	ret := []interface{}{}
+	for _, v := range dmsg.GetFieldByName("values").([]interface{}) {
+		vmsg, err := asDynamicMessage(v.(proto.Message))
+		if err != nil {
+			return nil, err
+		}
+		value, err := valueValue(vmsg)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, value)
+	}
+	return ret, nil
+}
+
+func valueValue(dmsg *dynamic.Message) (interface{}, error) {
+	for _, name := range []string{"number_value", "string_value", "bool_value", "struct_value", "list_value"} {
+		if !dmsg.HasFieldName(name) {
+			continue
+		}
+		value := dmsg.GetFieldByName(name)
+		switch name {
+		case "struct_value", "list_value":
+			vmsg, err := asDynamicMessage(value.(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			if name == "struct_value" {
+				return structValue(vmsg)
+			}
+			return listValue(vmsg)
+		}
+		return value, nil
+	}
+	// null_value
+	return nil, nil
+}
+
+// Converts a FieldMask path to lowerCamelCase as in the JSON mapping, like "a.field_name" to "a.fieldName"
+func fieldMaskPath(path string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range path {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Formats a duration as in the JSON mapping, like "1.5s"
+func formatDuration(seconds int64, nanos int32) string {
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign = "-"
+		if seconds < 0 {
+			seconds = -seconds
+		}
+		if nanos < 0 {
+			nanos = -nanos
+		}
+	}
+	if nanos == 0 {
+		return fmt.Sprintf("%s%ds", sign, seconds)
+	}
+	return fmt.Sprintf("%s%d.%s", sign, seconds, strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")) + "s"
+}
+
+// Converts a message to *dynamic.Message if it isn't one
+func asDynamicMessage(msg proto.Message) (*dynamic.Message, error) {
+	if dmsg, ok := msg.(*dynamic.Message); ok {
+		return dmsg, nil
+	}
+	return dynamic.AsDynamicMessage(msg)
+}
@@ -0,0 +1,183 @@
+package grpcget
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestWellKnownTypeOutputs(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper(WithDMHAnyResolver(&descriptorSourceAnyResolver{NewFileDescriptorSource(fd)}))
+
+	outputs := []struct {
+		name   string
+		output func(b *bytes.Buffer) InvokeOutput
+	}{
+		{name: "default", output: func(b *bytes.Buffer) InvokeOutput { return NewDefaultInvokeOutput(b) }},
+		{name: "json", output: func(b *bytes.Buffer) InvokeOutput {
+			o := NewJSONInvokeOutput(b)
+			o.Indent = ""
+			return o
+		}},
+		{name: "yaml", output: func(b *bytes.Buffer) InvokeOutput { return NewYAMLInvokeOutput(b) }},
+		{name: "xml", output: func(b *bytes.Buffer) InvokeOutput { return NewXMLInvokeOutput(b) }},
+		{name: "template", output: func(b *bytes.Buffer) InvokeOutput {
+			o, err := NewTemplateInvokeOutput(b, "{{json .v}}")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return o
+		}},
+		{name: "text", output: func(b *bytes.Buffer) InvokeOutput { return NewTextInvokeOutput(b) }},
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		json    string
+		value   interface{}
+		want    map[string]string
+	}{
+		{name: "struct", message: "W_struct", json: `{"v":{"a":1,"b":[true,null,"x"]}}`,
+			want: map[string]string{
+				"default":  "v: {\"a\":1,\"b\":[true,null,\"x\"]}\n",
+				"json":     "{\"v\":{\"a\":1,\"b\":[true,null,\"x\"]}}\n",
+				"yaml":     "v:\n  a: 1\n  b:\n  - true\n  - null\n  - x\n",
+				"xml":      "<response type=\"test.W_struct\">\n  <v>\n    <entry key=\"a\">1</entry>\n    <entry key=\"b\">\n      <item>true</item>\n      <item></item>\n      <item>x</item>\n    </entry>\n  </v>\n</response>\n",
+				"template": "{\"a\":1,\"b\":[true,null,\"x\"]}\n",
+				"text":     "v {\n  fields {\n    key: \"a\"\n    value {\n      number_value: 1\n    }\n  }\n  fields {\n    key: \"b\"\n    value {\n      list_value {\n        values {\n          bool_value: true\n        }\n        values {\n          null_value: NULL_VALUE\n        }\n        values {\n          string_value: \"x\"\n        }\n      }\n    }\n  }\n}\n",
+			}},
+		{name: "value", message: "W_value", json: `{"v":"str"}`,
+			want: map[string]string{
+				"default":  "v: str\n",
+				"json":     "{\"v\":\"str\"}\n",
+				"yaml":     "v: str\n",
+				"xml":      "<response type=\"test.W_value\">\n  <v>str</v>\n</response>\n",
+				"template": "\"str\"\n",
+				"text":     "v {\n  string_value: \"str\"\n}\n",
+			}},
+		{name: "list", message: "W_list", json: `{"v":[1,"x"]}`,
+			want: map[string]string{
+				"default":  "v: [1,\"x\"]\n",
+				"json":     "{\"v\":[1,\"x\"]}\n",
+				"yaml":     "v:\n- 1\n- x\n",
+				"xml":      "<response type=\"test.W_list\">\n  <v>\n    <item>1</item>\n    <item>x</item>\n  </v>\n</response>\n",
+				"template": "[1,\"x\"]\n",
+				"text":     "v {\n  values {\n    number_value: 1\n  }\n  values {\n    string_value: \"x\"\n  }\n}\n",
+			}},
+		{name: "fieldmask", message: "W_fieldmask", json: `{"v":{"paths":["a.b","c_d"]}}`,
+			want: map[string]string{
+				"default":  "v: a.b,cD\n",
+				"json":     "{\"v\":\"a.b,cD\"}\n",
+				"yaml":     "v: a.b,cD\n",
+				"xml":      "<response type=\"test.W_fieldmask\">\n  <v>a.b,cD</v>\n</response>\n",
+				"template": "\"a.b,cD\"\n",
+				"text":     "v {\n  paths: \"a.b\"\n  paths: \"c_d\"\n}\n",
+			}},
+		{name: "int64", message: "W_int64", json: `{"v":"5"}`,
+			want: map[string]string{
+				"default":  "v: 5\n",
+				"json":     "{\"v\":\"5\"}\n",
+				"yaml":     "v: 5\n",
+				"xml":      "<response type=\"test.W_int64\">\n  <v>5</v>\n</response>\n",
+				"template": "5\n",
+				"text":     "v {\n  value: 5\n}\n",
+			}},
+		{name: "string", message: "W_string", json: `{"v":"s"}`,
+			want: map[string]string{
+				"default":  "v: s\n",
+				"json":     "{\"v\":\"s\"}\n",
+				"yaml":     "v: s\n",
+				"xml":      "<response type=\"test.W_string\">\n  <v>s</v>\n</response>\n",
+				"template": "\"s\"\n",
+				"text":     "v {\n  value: \"s\"\n}\n",
+			}},
+		{name: "bytes", message: "W_bytes", json: `{"v":"aGk="}`,
+			want: map[string]string{
+				"default":  "v: aGk=\n",
+				"json":     "{\"v\":\"aGk=\"}\n",
+				"yaml":     "v: aGk=\n",
+				"xml":      "<response type=\"test.W_bytes\">\n  <v>aGk=</v>\n</response>\n",
+				"template": "\"aGk=\"\n",
+				"text":     "v {\n  value: \"hi\"\n}\n",
+			}},
+		{name: "any message", message: "W_any", json: `{"v":{"@type":"type.googleapis.com/test.Inner","name":"n","num":2}}`,
+			want: map[string]string{
+				"default":  "v: {\"@type\":\"type.googleapis.com/test.Inner\",\"name\":\"n\",\"num\":2}\n",
+				"json":     "{\"v\":{\"@type\":\"type.googleapis.com/test.Inner\",\"name\":\"n\",\"num\":2}}\n",
+				"yaml":     "v:\n  '@type': type.googleapis.com/test.Inner\n  name: \"n\"\n  num: 2\n",
+				"xml":      "<response type=\"test.W_any\">\n  <v type=\"type.googleapis.com/test.Inner\">\n    <name>n</name>\n    <num>2</num>\n  </v>\n</response>\n",
+				"template": "{\"@type\":\"type.googleapis.com/test.Inner\",\"name\":\"n\",\"num\":2}\n",
+				"text":     "v {\n  [type.googleapis.com/test.Inner] {\n    name: \"n\"\n    num: 2\n  }\n}\n",
+			}},
+		{name: "any duration", message: "W_any", json: `{"v":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"2s"}}`,
+			want: map[string]string{
+				"default":  "v: {\"@type\":\"type.googleapis.com/google.protobuf.Duration\",\"value\":\"2s\"}\n",
+				"json":     "{\"v\":{\"@type\":\"type.googleapis.com/google.protobuf.Duration\",\"value\":\"2s\"}}\n",
+				"yaml":     "v:\n  '@type': type.googleapis.com/google.protobuf.Duration\n  value: 2s\n",
+				"xml":      "<response type=\"test.W_any\">\n  <v type=\"type.googleapis.com/google.protobuf.Duration\">\n    <value>2s</value>\n  </v>\n</response>\n",
+				"template": "{\"@type\":\"type.googleapis.com/google.protobuf.Duration\",\"value\":\"2s\"}\n",
+				"text":     "v {\n  [type.googleapis.com/google.protobuf.Duration] {\n    seconds: 2\n  }\n}\n",
+			}},
+		{name: "any timestamp", message: "W_any", json: `{"v":{"@type":"type.googleapis.com/google.protobuf.Timestamp","value":"2020-01-02T03:04:05Z"}}`,
+			want: map[string]string{
+				"default":  "v: {\"@type\":\"type.googleapis.com/google.protobuf.Timestamp\",\"value\":\"2020-01-02T03:04:05Z\"}\n",
+				"json":     "{\"v\":{\"@type\":\"type.googleapis.com/google.protobuf.Timestamp\",\"value\":\"2020-01-02T03:04:05Z\"}}\n",
+				"yaml":     "v:\n  '@type': type.googleapis.com/google.protobuf.Timestamp\n  value: \"2020-01-02T03:04:05Z\"\n",
+				"xml":      "<response type=\"test.W_any\">\n  <v type=\"type.googleapis.com/google.protobuf.Timestamp\">\n    <value>2020-01-02T03:04:05Z</value>\n  </v>\n</response>\n",
+				"template": "{\"@type\":\"type.googleapis.com/google.protobuf.Timestamp\",\"value\":\"2020-01-02T03:04:05Z\"}\n",
+				"text":     "v {\n  [type.googleapis.com/google.protobuf.Timestamp] {\n    seconds: 1577934245\n  }\n}\n",
+			}},
+		{name: "any struct", message: "W_any", json: `{"v":{"@type":"type.googleapis.com/google.protobuf.Struct","value":{"a":"b"}}}`,
+			want: map[string]string{
+				"default":  "v: {\"@type\":\"type.googleapis.com/google.protobuf.Struct\",\"value\":{\"a\":\"b\"}}\n",
+				"json":     "{\"v\":{\"@type\":\"type.googleapis.com/google.protobuf.Struct\",\"value\":{\"a\":\"b\"}}}\n",
+				"yaml":     "v:\n  '@type': type.googleapis.com/google.protobuf.Struct\n  value:\n    a: b\n",
+				"xml":      "<response type=\"test.W_any\">\n  <v type=\"type.googleapis.com/google.protobuf.Struct\">\n    <value>\n      <entry key=\"a\">b</entry>\n    </value>\n  </v>\n</response>\n",
+				"template": "{\"@type\":\"type.googleapis.com/google.protobuf.Struct\",\"value\":{\"a\":\"b\"}}\n",
+				"text":     "v {\n  [type.googleapis.com/google.protobuf.Struct] {\n    fields {\n      key: \"a\"\n      value {\n        string_value: \"b\"\n      }\n    }\n  }\n}\n",
+			}},
+		// the JSON mapping can't output an Any of an unknown type, the outputs without a want fail
+		{name: "any unknown", message: "W_any", value: &anypb.Any{TypeUrl: "type.googleapis.com/test.Unknown", Value: []byte{1, 2}},
+			want: map[string]string{
+				"default":  "v: {\"@type\":\"type.googleapis.com/test.Unknown\",\"value\":\"AQI=\"}\n",
+				"yaml":     "v:\n  '@type': type.googleapis.com/test.Unknown\n  value: AQI=\n",
+				"xml":      "<response type=\"test.W_any\">\n  <v type=\"type.googleapis.com/test.Unknown\">\n    <value>AQI=</value>\n  </v>\n</response>\n",
+				"template": "{\"@type\":\"type.googleapis.com/test.Unknown\",\"value\":\"AQI=\"}\n",
+				"text":     "v {\n  type_url: \"type.googleapis.com/test.Unknown\"\n  value: \"\\001\\002\"\n}\n",
+			}},
+	}
+
+	for _, tt := range tests {
+		for _, o := range outputs {
+			t.Run(tt.name+"/"+o.name, func(t *testing.T) {
+				msg := testMessage(t, fd, tt.message)
+				if tt.value != nil {
+					if err := msg.TrySetFieldByName("v", tt.value); err != nil {
+						t.Fatalf("Error setting value: %v", err)
+					}
+				} else if err := msg.UnmarshalJSON([]byte(tt.json)); err != nil {
+					t.Fatalf("Error setting value: %v", err)
+				}
+
+				var b bytes.Buffer
+				err := o.output(&b).OutputInvoke(dmh, msg)
+				want, ok := tt.want[o.name]
+				if !ok {
+					if err == nil {
+						t.Fatalf("Expected an error, output is %q", b.String())
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Error in output: %v", err)
+				}
+				if b.String() != want {
+					t.Errorf("Output is %q, want %q", b.String(), want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,563 @@
+package grpcget
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//
+// XML outputs
+//
+// Element schema, elements without content are output as empty start and end element pairs:
+//
+// Service list:
+//   <services>
+//     <service name="helloworld.Greeter"></service>
+//   </services>
+//
+// Service:
+//   <service name="helloworld.Greeter">
+//     <method name="SayHello" input="helloworld.HelloRequest" output="helloworld.HelloReply" client-streaming="false" server-streaming="false"></method>
+//   </service>
+//
+// Describe:
+//   <file name="helloworld.proto" package="helloworld"> services, messages and enums </file>
+//   <service name="helloworld.Greeter"> methods </service>
+//   <method name="SayHello" ...>
+//     <request> message </request>
+//     <response> message </response>
+//   </method>
+//   <message name="helloworld.HelloRequest"> fields, nested messages and enums </message>
+//   <field name="name" number="1" label="optional" type="string" json-name="name"></field>
+//     Optional attributes: type-name (message and enum types), oneof, map-key and map-value (maps, with type="map")
+//   <enum name="helloworld.Status">
+//     <value name="ACTIVE" number="1"></value>
+//   </enum>
+//
+// Invoke:
+//   <response type="helloworld.HelloReply">
+//     <message>Hello</message>                  scalar fields, enums by name and bytes as base64
+//     <inner><value>x</value></inner>           message fields
+//     <tags>a</tags><tags>b</tags>              repeated fields are repeated elements
+//     <labels><entry key="env">prod</entry></labels>  maps have an entry element per key
+//     <extension name="pkg.ext">1</extension>   extension fields
+//     <created>2020-01-01T00:00:00Z</created>   well-known types as in their JSON mapping, with Struct fields
+//                                               as entry elements, ListValue values as item elements and
+//                                               Any as <any type="type URL"> with the packed message fields,
+//                                               or a value element if it is a well-known type
+//   </response>
+//
+//   Streaming responses are wrapped in a stream element, followed by the status and trailers, unless they are
+//   output by an InvokeMetadataOutput:
+//   <stream method="helloworld.Greeter.SayHello">
+//     <response>...</response>
+//     <status code="OK"></status>
+//     <trailer name="key">value</trailer>
+//   </stream>
+//
+
+//
+// ServiceListOutput - XML
+//
+type XMLServiceListOutput struct {
+	Out io.Writer
+}
+
+func NewXMLServiceListOutput(out io.Writer) *XMLServiceListOutput {
+	return &XMLServiceListOutput{
+		Out: out,
+	}
+}
+
+func (d *XMLServiceListOutput) OutputServiceList(services []string) error {
+	e := newXMLEncoder(d.Out)
+
+	err := xmlStart(e, "services")
+	if err != nil {
+		return err
+	}
+	for _, s := range services {
+		err = xmlEmpty(e, "service", "name", s)
+		if err != nil {
+			return err
+		}
+	}
+	err = xmlEnd(e, "services")
+	if err != nil {
+		return err
+	}
+
+	return xmlFlush(e, d.Out)
+}
+
+//
+// ServiceOutput - XML
+//
+type XMLServiceOutput struct {
+	Out io.Writer
+}
+
+func NewXMLServiceOutput(out io.Writer) *XMLServiceOutput {
+	return &XMLServiceOutput{
+		Out: out,
+	}
+}
+
+func (d *XMLServiceOutput) OutputService(service *desc.ServiceDescriptor) error {
+	e := newXMLEncoder(d.Out)
+
+	err := xmlDescribeService(e, service)
+	if err != nil {
+		return err
+	}
+
+	return xmlFlush(e, d.Out)
+}
+
+//
+// DescribeOutput - XML
+//
+type XMLDescribeOutput struct {
+	Out io.Writer
+}
+
+func NewXMLDescribeOutput(out io.Writer) *XMLDescribeOutput {
+	return &XMLDescribeOutput{
+		Out: out,
+	}
+}
+
+func (d *XMLDescribeOutput) OutputDescribe(descriptor desc.Descriptor) error {
+	e := newXMLEncoder(d.Out)
+
+	var err error
+	switch sd := descriptor.(type) {
+	case *desc.FileDescriptor:
+		err = xmlDescribeFile(e, sd)
+	case *desc.ServiceDescriptor:
+		err = xmlDescribeService(e, sd)
+	case *desc.MethodDescriptor:
+		err = xmlDescribeMethod(e, sd, true)
+	case *desc.MessageDescriptor:
+		err = xmlDescribeMessage(e, sd)
+	case *desc.EnumDescriptor:
+		err = xmlDescribeEnum(e, sd)
+	case *desc.FieldDescriptor:
+		err = xmlDescribeField(e, sd)
+	default:
+		err = xmlEmpty(e, "unknown", "name", sd.GetFullyQualifiedName())
+	}
+	if err != nil {
+		return err
+	}
+
+	return xmlFlush(e, d.Out)
+}
+
+func xmlDescribeFile(e *xml.Encoder, file *desc.FileDescriptor) error {
+	err := xmlStart(e, "file", "name", file.GetName(), "package", file.GetPackage())
+	if err != nil {
+		return err
+	}
+	for _, svc := range file.GetServices() {
+		err = xmlDescribeService(e, svc)
+		if err != nil {
+			return err
+		}
+	}
+	for _, msg := range file.GetMessageTypes() {
+		err = xmlDescribeMessage(e, msg)
+		if err != nil {
+			return err
+		}
+	}
+	for _, enum := range file.GetEnumTypes() {
+		err = xmlDescribeEnum(e, enum)
+		if err != nil {
+			return err
+		}
+	}
+	return xmlEnd(e, "file")
+}
+
+func xmlDescribeService(e *xml.Encoder, svc *desc.ServiceDescriptor) error {
+	err := xmlStart(e, "service", "name", svc.GetFullyQualifiedName())
+	if err != nil {
+		return err
+	}
+	for _, mt := range svc.GetMethods() {
+		err = xmlDescribeMethod(e, mt, false)
+		if err != nil {
+			return err
+		}
+	}
+	return xmlEnd(e, "service")
+}
+
+func xmlDescribeMethod(e *xml.Encoder, mtd *desc.MethodDescriptor, complete bool) error {
+	attrs := []string{
+		"name", mtd.GetName(),
+		"input", mtd.GetInputType().GetFullyQualifiedName(),
+		"output", mtd.GetOutputType().GetFullyQualifiedName(),
+		"client-streaming", strconv.FormatBool(mtd.IsClientStreaming()),
+		"server-streaming", strconv.FormatBool(mtd.IsServerStreaming()),
+	}
+	if !complete {
+		return xmlEmpty(e, "method", attrs...)
+	}
+
+	err := xmlStart(e, "method", attrs...)
+	if err != nil {
+		return err
+	}
+	for _, msg := range []struct {
+		name string
+		md   *desc.MessageDescriptor
+	}{{"request", mtd.GetInputType()}, {"response", mtd.GetOutputType()}} {
+		err = xmlStart(e, msg.name)
+		if err != nil {
+			return err
+		}
+		err = xmlDescribeMessage(e, msg.md)
+		if err != nil {
+			return err
+		}
+		err = xmlEnd(e, msg.name)
+		if err != nil {
+			return err
+		}
+	}
+	return xmlEnd(e, "method")
+}
+
+func xmlDescribeMessage(e *xml.Encoder, msg *desc.MessageDescriptor) error {
+	err := xmlStart(e, "message", "name", msg.GetFullyQualifiedName())
+	if err != nil {
+		return err
+	}
+	for _, fld := range msg.GetFields() {
+		err = xmlDescribeField(e, fld)
+		if err != nil {
+			return err
+		}
+	}
+	for _, nested := range msg.GetNestedMessageTypes() {
+		// map entries are described in the map field
+		if nested.IsMapEntry() {
+			continue
+		}
+		err = xmlDescribeMessage(e, nested)
+		if err != nil {
+			return err
+		}
+	}
+	for _, enum := range msg.GetNestedEnumTypes() {
+		err = xmlDescribeEnum(e, enum)
+		if err != nil {
+			return err
+		}
+	}
+	return xmlEnd(e, "message")
+}
+
+func xmlDescribeField(e *xml.Encoder, fld *desc.FieldDescriptor) error {
+	attrs := []string{
+		"name", fld.GetName(),
+		"number", strconv.Itoa(int(fld.GetNumber())),
+		"label", fieldLabelName(fld),
+		"type", fieldTypeName(fld),
+		"json-name", fld.GetJSONName(),
+	}
+	if fld.IsMap() {
+		attrs = append(attrs, "map-key", fieldTypeName(fld.GetMapKeyType()), "map-value", fieldTypeName(fld.GetMapValueType()))
+		if tn := fieldTypeRefName(fld.GetMapValueType()); tn != "" {
+			attrs = append(attrs, "type-name", tn)
+		}
+	} else if tn := fieldTypeRefName(fld); tn != "" {
+		attrs = append(attrs, "type-name", tn)
+	}
+	if fld.GetOneOf() != nil {
+		attrs = append(attrs, "oneof", fld.GetOneOf().GetName())
+	}
+	return xmlEmpty(e, "field", attrs...)
+}
+
+func xmlDescribeEnum(e *xml.Encoder, enum *desc.EnumDescriptor) error {
+	err := xmlStart(e, "enum", "name", enum.GetFullyQualifiedName())
+	if err != nil {
+		return err
+	}
+	for _, ev := range enum.GetValues() {
+		err = xmlEmpty(e, "value", "name", ev.GetName(), "number", strconv.Itoa(int(ev.GetNumber())))
+		if err != nil {
+			return err
+		}
+	}
+	return xmlEnd(e, "enum")
+}
+
+//
+// InvokeOutput - XML
+//
+type XMLInvokeOutput struct {
+	Out io.Writer
+
+	streamEncoder *xml.Encoder
+}
+
+func NewXMLInvokeOutput(out io.Writer) *XMLInvokeOutput {
+	return &XMLInvokeOutput{
+		Out: out,
+	}
+}
+
+func (d *XMLInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return err
+	}
+
+	e := newXMLEncoder(d.Out)
+
+	err = xmlStart(e, "response", "type", mv.Descriptor.GetFullyQualifiedName())
+	if err != nil {
+		return err
+	}
+	err = xmlMessageValue(e, mv)
+	if err != nil {
+		return err
+	}
+	err = xmlEnd(e, "response")
+	if err != nil {
+		return err
+	}
+
+	return xmlFlush(e, d.Out)
+}
+
+func (d *XMLInvokeOutput) OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error {
+	d.streamEncoder = newXMLEncoder(d.Out)
+	return xmlStart(d.streamEncoder, "stream", "method", method.GetFullyQualifiedName())
+}
+
+func (d *XMLInvokeOutput) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return err
+	}
+
+	e := d.streamEncoder
+
+	err = xmlStart(e, "response", "type", mv.Descriptor.GetFullyQualifiedName())
+	if err != nil {
+		return err
+	}
+	err = xmlMessageValue(e, mv)
+	if err != nil {
+		return err
+	}
+	err = xmlEnd(e, "response")
+	if err != nil {
+		return err
+	}
+
+	// output messages as they arrive
+	return e.Flush()
+}
+
+func (d *XMLInvokeOutput) OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	e := d.streamEncoder
+	d.streamEncoder = nil
+
+	// status is nil when output by the InvokeMetadataOutput
+	if st != nil {
+		err := xmlText(e, "status", st.Message(), "code", st.Code().String())
+		if err != nil {
+			return err
+		}
+	}
+	var err error
+	for _, tk := range sortedMetadataKeys(trailers) {
+		for _, tv := range trailers[tk] {
+			err = xmlText(e, "trailer", tv, "name", tk)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = xmlEnd(e, "stream")
+	if err != nil {
+		return err
+	}
+
+	return xmlFlush(e, d.Out)
+}
+
+func xmlMessageValue(e *xml.Encoder, msg *MessageValue) error {
+	for _, f := range msg.Fields {
+		name := f.Field.GetName()
+		var attrs []string
+		if f.Field.IsExtension() {
+			name = "extension"
+			attrs = []string{"name", f.Field.GetFullyQualifiedName()}
+		}
+
+		var err error
+		switch xvalue := f.Value.(type) {
+		case []*MapEntryValue:
+			err = xmlStart(e, name, attrs...)
+			if err != nil {
+				return err
+			}
+			for _, entry := range xvalue {
+				err = xmlFieldValue(e, "entry", entry.Value, "key", fmt.Sprint(entry.Key))
+				if err != nil {
+					return err
+				}
+			}
+			err = xmlEnd(e, name)
+		case []interface{}:
+			for _, item := range xvalue {
+				err = xmlFieldValue(e, name, item, attrs...)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			err = xmlFieldValue(e, name, xvalue, attrs...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func xmlFieldValue(e *xml.Encoder, name string, value interface{}, attrs ...string) error {
+	var text string
+	switch xvalue := value.(type) {
+	case *MessageValue:
+		err := xmlStart(e, name, attrs...)
+		if err != nil {
+			return err
+		}
+		err = xmlMessageValue(e, xvalue)
+		if err != nil {
+			return err
+		}
+		return xmlEnd(e, name)
+	case *WellKnownValue:
+		return xmlFieldValue(e, name, xvalue.Value, attrs...)
+	case *AnyValue:
+		err := xmlStart(e, name, append(attrs, "type", xvalue.TypeURL)...)
+		if err != nil {
+			return err
+		}
+		if xvalue.WellKnown != nil {
+			err = xmlFieldValue(e, "value", xvalue.WellKnown)
+		} else if xvalue.Message != nil {
+			err = xmlMessageValue(e, xvalue.Message)
+		} else {
+			err = xmlFieldValue(e, "value", xvalue.Raw)
+		}
+		if err != nil {
+			return err
+		}
+		return xmlEnd(e, name)
+	case []*MapEntryValue:
+		// google.protobuf.Struct
+		err := xmlStart(e, name, attrs...)
+		if err != nil {
+			return err
+		}
+		for _, entry := range xvalue {
+			err = xmlFieldValue(e, "entry", entry.Value, "key", fmt.Sprint(entry.Key))
+			if err != nil {
+				return err
+			}
+		}
+		return xmlEnd(e, name)
+	case []interface{}:
+		// google.protobuf.ListValue
+		err := xmlStart(e, name, attrs...)
+		if err != nil {
+			return err
+		}
+		for _, item := range xvalue {
+			err = xmlFieldValue(e, "item", item)
+			if err != nil {
+				return err
+			}
+		}
+		return xmlEnd(e, name)
+	case nil:
+		// google.protobuf.NullValue
+		return xmlEmpty(e, name, attrs...)
+	default:
+		text = formatScalarValue(value)
+	}
+	return xmlText(e, name, text, attrs...)
+}
+
+//
+// XML helpers, attrs are name and value pairs
+//
+
+func newXMLEncoder(out io.Writer) *xml.Encoder {
+	e := xml.NewEncoder(out)
+	e.Indent("", "  ")
+	return e
+}
+
+func xmlStartElement(name string, attrs ...string) xml.StartElement {
+	se := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		se.Attr = append(se.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: attrs[i+1]})
+	}
+	return se
+}
+
+func xmlStart(e *xml.Encoder, name string, attrs ...string) error {
+	return e.EncodeToken(xmlStartElement(name, attrs...))
+}
+
+func xmlEnd(e *xml.Encoder, name string) error {
+	return e.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func xmlEmpty(e *xml.Encoder, name string, attrs ...string) error {
+	err := xmlStart(e, name, attrs...)
+	if err != nil {
+		return err
+	}
+	return xmlEnd(e, name)
+}
+
+func xmlText(e *xml.Encoder, name string, text string, attrs ...string) error {
+	err := xmlStart(e, name, attrs...)
+	if err != nil {
+		return err
+	}
+	err = e.EncodeToken(xml.CharData(text))
+	if err != nil {
+		return err
+	}
+	return xmlEnd(e, name)
+}
+
+// Flushes the encoder and ends the document with a new line
+func xmlFlush(e *xml.Encoder, out io.Writer) error {
+	err := e.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out)
+	return err
+}
@@ -0,0 +1,393 @@
+package grpcget
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"gopkg.in/yaml.v2"
+)
+
+//
+// YAMLInvokeParamSetter
+//
+// Params are a YAML document with the same structure as the proto3 JSON mapping, merged into the request
+// message, so fields not in the document keep the values set by previous setters. Fields can use the proto
+// or the JSON names. Errors include the field path, and the line when it can be found.
+//
+type YAMLInvokeParamSetter struct {
+	Data []byte
+}
+
+func NewYAMLInvokeParamSetter(data []byte) *YAMLInvokeParamSetter {
+	return &YAMLInvokeParamSetter{
+		Data: data,
+	}
+}
+
+func (i *YAMLInvokeParamSetter) SetInvokeParam(dmh *DynMsgHelper, req *dynamic.Message) error {
+	var value interface{}
+	err := yaml.Unmarshal(i.Data, &value)
+	if err != nil {
+		// yaml errors include the line
+		return fmt.Errorf("Error parsing YAML request: %v", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if value == nil {
+		return nil
+	}
+
+	err = yamlSetMessage(dmh, req, nil, value)
+	if err != nil {
+		if perr, ok := err.(*yamlPathError); ok {
+			path := yamlPathString(perr.path)
+			if line := yamlFieldLine(i.Data, perr.path); line > 0 {
+				return fmt.Errorf("Error parsing YAML request at line %d, field %s: %v", line, path, perr.err)
+			}
+			return fmt.Errorf("Error parsing YAML request, field %s: %v", path, perr.err)
+		}
+		return fmt.Errorf("Error parsing YAML request: %v", err)
+	}
+
+	return nil
+}
+
+// Error in a field, the path has field names and list indexes
+type yamlPathError struct {
+	path []interface{}
+	err  error
+}
+
+func (e *yamlPathError) Error() string {
+	return fmt.Sprintf("%s: %v", yamlPathString(e.path), e.err)
+}
+
+func yamlPathString(path []interface{}) string {
+	var sb strings.Builder
+	for _, p := range path {
+		switch xp := p.(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", xp)
+		default:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(fmt.Sprint(xp))
+		}
+	}
+	return sb.String()
+}
+
+func yamlSetMessage(dmh *DynMsgHelper, msg *dynamic.Message, path []interface{}, value interface{}) error {
+	md := msg.GetMessageDescriptor()
+
+	values, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return &yamlPathError{path: path, err: fmt.Errorf("Expected a mapping for message %s", md.GetFullyQualifiedName())}
+	}
+
+	// sorted so errors are reported in a stable order
+	var keys []string
+	byName := make(map[string]interface{})
+	for k, v := range values {
+		name := fmt.Sprint(k)
+		keys = append(keys, name)
+		byName[name] = v
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		fpath := append(append([]interface{}{}, path...), name)
+		v := byName[name]
+
+		fld := md.FindFieldByName(name)
+		if fld == nil {
+			fld = md.FindFieldByJSONName(name)
+		}
+		if fld == nil {
+			return &yamlPathError{path: fpath, err: fmt.Errorf("Field not found in message %s", md.GetFullyQualifiedName())}
+		}
+
+		var err error
+		switch {
+		case yamlIsNestedMessage(fld) && !fld.IsRepeated():
+			if _, is_map := v.(map[interface{}]interface{}); !is_map {
+				break
+			}
+			var nested *dynamic.Message
+			if msg.HasField(fld) {
+				nested, err = asDynamicMessage(msg.GetField(fld).(proto.Message))
+			} else {
+				nested = dynamic.NewMessage(fld.GetMessageType())
+			}
+			if err == nil {
+				err = yamlSetMessage(dmh, nested, fpath, v)
+			}
+			if err == nil {
+				err = msg.TrySetField(fld, nested)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		case yamlIsNestedMessage(fld) && !fld.IsMap():
+			items, is_list := v.([]interface{})
+			if !is_list {
+				break
+			}
+			for idx, item := range items {
+				nested := dynamic.NewMessage(fld.GetMessageType())
+				err = yamlSetMessage(dmh, nested, append(append([]interface{}{}, fpath...), idx), item)
+				if err == nil {
+					err = msg.TryAddRepeatedField(fld, nested)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		case yamlIsAny(fld) && !fld.IsMap():
+			items, is_list := v.([]interface{})
+			if !fld.IsRepeated() {
+				items = []interface{}{v}
+			} else if !is_list {
+				break
+			}
+			var anys []*dynamic.Message
+			for idx, item := range items {
+				ipath := fpath
+				if fld.IsRepeated() {
+					ipath = append(append([]interface{}{}, fpath...), idx)
+				}
+				value, err := yamlAnyMessage(dmh, fld.GetMessageType(), ipath, item)
+				if err != nil {
+					return err
+				}
+				if value == nil {
+					break
+				}
+				anys = append(anys, value)
+			}
+			if len(anys) < len(items) {
+				// well-known types are set with their JSON mapping
+				break
+			}
+			for _, value := range anys {
+				if fld.IsRepeated() {
+					err = msg.TryAddRepeatedField(fld, value)
+				} else {
+					err = msg.TrySetField(fld, value)
+				}
+				if err != nil {
+					return &yamlPathError{path: fpath, err: err}
+				}
+			}
+			continue
+		}
+
+		// scalars, maps and well-known types are set with their JSON mapping
+		err = yamlSetJSONField(dmh, msg, fld, v)
+		if err != nil {
+			return &yamlPathError{path: fpath, err: err}
+		}
+	}
+
+	return nil
+}
+
+// Whether the field is a message that is set field by field, well-known types are set with their JSON mapping
+func yamlIsNestedMessage(fld *desc.FieldDescriptor) bool {
+	return fld.GetMessageType() != nil && !yamlIsWellKnownType(fld.GetMessageType())
+}
+
+func yamlIsWellKnownType(md *desc.MessageDescriptor) bool {
+	return strings.HasPrefix(md.GetFullyQualifiedName(), "google.protobuf.")
+}
+
+func yamlIsAny(fld *desc.FieldDescriptor) bool {
+	return fld.GetMessageType() != nil && fld.GetMessageType().GetFullyQualifiedName() == "google.protobuf.Any"
+}
+
+// Packs a mapping with the "@type" key in an Any message, with the packed message set field by field like other
+// nested messages. Returns nil if there is no "@type" or the packed message is a well-known type, which are set with
+// their JSON mapping.
+func yamlAnyMessage(dmh *DynMsgHelper, anyType *desc.MessageDescriptor, path []interface{}, value interface{}) (*dynamic.Message, error) {
+	values, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil
+	}
+	typeURL, ok := values["@type"].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	md, err := dmh.FindAnyMessageDescriptor(typeURL)
+	if err != nil {
+		return nil, &yamlPathError{path: append(append([]interface{}{}, path...), "@type"), err: err}
+	}
+	if yamlIsWellKnownType(md) {
+		return nil, nil
+	}
+
+	fields := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		if k != "@type" {
+			fields[k] = v
+		}
+	}
+	packed := dynamic.NewMessage(md)
+	err = yamlSetMessage(dmh, packed, path, fields)
+	if err != nil {
+		return nil, err
+	}
+	data, err := packed.Marshal()
+	if err != nil {
+		return nil, &yamlPathError{path: path, err: err}
+	}
+
+	ret := dynamic.NewMessage(anyType)
+	ret.SetFieldByName("type_url", typeURL)
+	ret.SetFieldByName("value", data)
+	return ret, nil
+}
+
+func yamlSetJSONField(dmh *DynMsgHelper, msg *dynamic.Message, fld *desc.FieldDescriptor, value interface{}) error {
+	value = yamlJSONValue(value)
+
+	// unquoted YAML scalars are accepted for string fields and string map values
+	if fld.GetType() == descriptor.FieldDescriptorProto_TYPE_STRING && !fld.IsMap() {
+		if items, ok := value.([]interface{}); ok {
+			for i, item := range items {
+				items[i] = yamlStringValue(item)
+			}
+		} else {
+			value = yamlStringValue(value)
+		}
+	} else if fld.IsMap() && fld.GetMapValueType().GetType() == descriptor.FieldDescriptorProto_TYPE_STRING {
+		if entries, ok := value.(map[string]interface{}); ok {
+			for k, v := range entries {
+				entries[k] = yamlStringValue(v)
+			}
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{fld.GetName(): value})
+	if err != nil {
+		return err
+	}
+
+	u := &jsonpb.Unmarshaler{
+		AnyResolver: &jsonAnyResolver{dmh: dmh},
+	}
+
+	err = msg.UnmarshalMergeJSONPB(u, data)
+	if err != nil {
+		// the error would refer to the JSON generated from the YAML
+		typeName := fieldTypeRefName(fld)
+		if typeName == "" || fld.IsMap() {
+			typeName = fieldTypeName(fld)
+		}
+		return fmt.Errorf("Invalid value for field of type %s: %v", typeName, err)
+	}
+	return nil
+}
+
+// Converts a YAML value to a value that can be encoded as JSON
+func yamlJSONValue(value interface{}) interface{} {
+	switch xvalue := value.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(xvalue))
+		for k, v := range xvalue {
+			ret[fmt.Sprint(k)] = yamlJSONValue(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(xvalue))
+		for i, v := range xvalue {
+			ret[i] = yamlJSONValue(v)
+		}
+		return ret
+	case time.Time:
+		return xvalue.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+func yamlStringValue(value interface{}) interface{} {
+	switch value.(type) {
+	case int, int64, uint64, float64, bool:
+		return fmt.Sprint(value)
+	}
+	return value
+}
+
+// Returns the line of a field path in a YAML document in block style, or 0 if it can't be found
+func yamlFieldLine(data []byte, path []interface{}) int {
+	lines := strings.Split(string(data), "\n")
+
+	current, indent := 0, -1
+	for _, p := range path {
+		found := false
+		count, itemIndent, keyIndent := 0, -1, -1
+		for li := current; li < len(lines); li++ {
+			content := strings.TrimRight(lines[li], " \t\r")
+			trimmed := strings.TrimLeft(content, " ")
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			lindent := len(content) - len(trimmed)
+			_, is_index := p.(int)
+			if li > current && (lindent < indent || lindent == indent && !(is_index && strings.HasPrefix(trimmed, "-"))) {
+				// out of the parent, list items can have the same indentation as their key
+				break
+			}
+
+			switch xp := p.(type) {
+			case int:
+				if itemIndent == -1 && strings.HasPrefix(trimmed, "-") {
+					itemIndent = lindent
+				}
+				if lindent != itemIndent || !strings.HasPrefix(trimmed, "-") {
+					continue
+				}
+				if count == xp {
+					// the keys of the item are after the "- "
+					current, indent, found = li, lindent, true
+					lines[li] = strings.Repeat(" ", lindent+1) + trimmed[1:]
+				}
+				count++
+			default:
+				item := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+				// only the keys of the parent mapping, not the ones nested in its values
+				kindent := lindent + len(trimmed) - len(item)
+				if keyIndent == -1 && kindent > indent {
+					keyIndent = kindent
+				}
+				if kindent != keyIndent {
+					continue
+				}
+				key := fmt.Sprint(xp)
+				for _, quote := range []string{"", `"`, "'"} {
+					if strings.HasPrefix(item, quote+key+quote+":") {
+						current, indent, found = li, kindent, true
+						break
+					}
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+
+	return current + 1
+}
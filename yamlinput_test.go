@@ -0,0 +1,90 @@
+package grpcget
+
+import "testing"
+
+func TestYAMLFieldLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		path []interface{}
+		want int
+	}{
+		{name: "root", data: "name: a\nvalue: 1\n", path: []interface{}{"value"}, want: 2},
+		{name: "root after nested", data: "inner:\n  value: 1\nvalue: 2\n", path: []interface{}{"value"}, want: 3},
+		{name: "nested", data: "inner:\n  value: 1\nvalue: 2\n", path: []interface{}{"inner", "value"}, want: 2},
+		{name: "deep nested", data: "inner:\n  deep:\n    value: 1\n  value: 2\n", path: []interface{}{"inner", "deep", "value"}, want: 3},
+		{name: "not in parent", data: "inner:\n  other: 1\nvalue: 2\n", path: []interface{}{"inner", "value"}, want: 0},
+		{name: "list same indent", data: "items:\n- name: a\n- name: b\n  state: X\n", path: []interface{}{"items", 1, "state"}, want: 4},
+		{name: "list indented", data: "items:\n  - name: a\n  - name: b\n    state: X\n", path: []interface{}{"items", 1, "state"}, want: 4},
+		{name: "list first key", data: "items:\n  - name: a\n  - name: b\n", path: []interface{}{"items", 1, "name"}, want: 3},
+		{name: "list nested list", data: "items:\n  - name: a\n    sub:\n      - 1\n      - 2\n  - name: b\n", path: []interface{}{"items", 1, "name"}, want: 6},
+		{name: "list scalar", data: "tags:\n- a\n- b\n", path: []interface{}{"tags", 1}, want: 3},
+		{name: "list index out of range", data: "items:\n- name: a\n", path: []interface{}{"items", 1}, want: 0},
+		{name: "quoted keys", data: "\"inner\":\n  'value': 1\n", path: []interface{}{"inner", "value"}, want: 2},
+		{name: "comments and blank lines", data: "# comment\ninner:\n  # value: 0\n\n  value: 1\n", path: []interface{}{"inner", "value"}, want: 5},
+		{name: "missing", data: "name: a\n", path: []interface{}{"missing"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := yamlFieldLine([]byte(tt.data), tt.path); got != tt.want {
+				t.Errorf("Line of %s is %d, want %d", yamlPathString(tt.path), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLInvokeParamSetter(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper(WithDMHAnyResolver(&descriptorSourceAnyResolver{NewFileDescriptorSource(fd)}))
+
+	tests := []struct {
+		name    string
+		message string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{name: "unquoted strings", message: "Params", data: "tags: [1, true, x]\n",
+			want: `{"tags":["1","true","x"]}`},
+		{name: "unquoted map values", message: "Params", data: "labels:\n  a: 123\n  b: false\n  c: x\n",
+			want: `{"labels":{"a":"123","b":"false","c":"x"}}`},
+		{name: "any message", message: "W_any", data: "v:\n  '@type': type.googleapis.com/test.Inner\n  name: 123\n  num: 2\n",
+			want: `{"v":{"@type":"type.googleapis.com/test.Inner","name":"123","num":2}}`},
+		{name: "any message json names", message: "W_any", data: "v: {'@type': type.googleapis.com/test.Nested, custom: 1, child: {v: 2}}\n",
+			want: `{"v":{"@type":"type.googleapis.com/test.Nested","child":{"v":2},"custom":"1"}}`},
+		{name: "any well-known type", message: "W_any", data: "v:\n  '@type': type.googleapis.com/google.protobuf.Duration\n  value: 2s\n",
+			want: `{"v":{"@type":"type.googleapis.com/google.protobuf.Duration","value":"2s"}}`},
+		{name: "repeated any", message: "W_anys",
+			data: "v:\n- '@type': type.googleapis.com/test.Inner\n  name: 1\n- '@type': type.googleapis.com/test.Item\n  name: b\n",
+			want: `{"v":[{"@type":"type.googleapis.com/test.Inner","name":"1"},{"@type":"type.googleapis.com/test.Item","name":"b"}]}`},
+		{name: "any unknown type", message: "W_any", data: "v:\n  '@type': type.googleapis.com/test.Unknown\n",
+			wantErr: "Error parsing YAML request at line 2, field v.@type: Unknown message type test.Unknown"},
+		{name: "any unknown field", message: "W_anys", data: "v:\n- '@type': type.googleapis.com/test.Inner\n  nope: 1\n",
+			wantErr: "Error parsing YAML request at line 3, field v[0].nope: Field not found in message test.Inner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage(t, fd, tt.message)
+			err := NewYAMLInvokeParamSetter([]byte(tt.data)).SetInvokeParam(dmh, msg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Error is %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error setting params: %v", err)
+			}
+
+			b, err := msg.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Message is %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package grpcget
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+//
+// InvokeOutput - YAML
+//
+// Outputs the response as YAML, using the proto field names. Enums are output by name, bytes as base64, and
+// fields converted by DynMsgHelper field getters as strings. Streamed messages are output as separate documents.
+//
+type YAMLInvokeOutput struct {
+	Out io.Writer
+
+	streamCount int
+}
+
+func NewYAMLInvokeOutput(out io.Writer) *YAMLInvokeOutput {
+	return &YAMLInvokeOutput{
+		Out: out,
+	}
+}
+
+func (d *YAMLInvokeOutput) OutputInvoke(dmh *DynMsgHelper, value proto.Message) error {
+	mv, err := dmh.MessageValue(value)
+	if err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(YAMLValue(mv))
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Out.Write(b)
+	return err
+}
+
+func (d *YAMLInvokeOutput) OutputInvokeStreamBegin(dmh *DynMsgHelper, method *desc.MethodDescriptor) error {
+	d.streamCount = 0
+	return nil
+}
+
+func (d *YAMLInvokeOutput) OutputInvokeStreamMessage(dmh *DynMsgHelper, value proto.Message) error {
+	// separate the documents
+	if d.streamCount > 0 {
+		_, err := fmt.Fprintln(d.Out, "---")
+		if err != nil {
+			return err
+		}
+	}
+	d.streamCount++
+
+	return d.OutputInvoke(dmh, value)
+}
+
+func (d *YAMLInvokeOutput) OutputInvokeStreamEnd(dmh *DynMsgHelper, st *status.Status, trailers metadata.MD) error {
+	return nil
+}
+
+// Converts a MessageValue or one of its field values to a value that can be marshaled by yaml.v2, keeping
+// the field order. Well-known types are converted as in their JSON mapping.
+func YAMLValue(value interface{}) interface{} {
+	switch xvalue := value.(type) {
+	case *MessageValue:
+		ret := yaml.MapSlice{}
+		for _, f := range xvalue.Fields {
+			ret = append(ret, yaml.MapItem{Key: f.Field.GetName(), Value: YAMLValue(f.Value)})
+		}
+		return ret
+	case []*MapEntryValue:
+		ret := yaml.MapSlice{}
+		for _, entry := range xvalue {
+			ret = append(ret, yaml.MapItem{Key: entry.Key, Value: YAMLValue(entry.Value)})
+		}
+		return ret
+	case []interface{}:
+		var ret []interface{}
+		for _, item := range xvalue {
+			ret = append(ret, YAMLValue(item))
+		}
+		return ret
+	case *WellKnownValue:
+		return YAMLValue(xvalue.Value)
+	case *AnyValue:
+		ret := yaml.MapSlice{{Key: "@type", Value: xvalue.TypeURL}}
+		if xvalue.Message == nil {
+			return append(ret, yaml.MapItem{Key: "value", Value: YAMLValue(xvalue.Raw)})
+		}
+		if xvalue.WellKnown != nil {
+			return append(ret, yaml.MapItem{Key: "value", Value: YAMLValue(xvalue.WellKnown)})
+		}
+		return append(ret, YAMLValue(xvalue.Message).(yaml.MapSlice)...)
+	case EnumValue:
+		if xvalue.Name != "" {
+			return xvalue.Name
+		}
+		return xvalue.Number
+	case []byte:
+		return base64.StdEncoding.EncodeToString(xvalue)
+	}
+	return value
+}
@@ -0,0 +1,91 @@
+package grpcget
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestYAMLInvokeOutput(t *testing.T) {
+	fd := testFileDescriptor(t)
+
+	scalar := func(name string, value interface{}) *dynamic.Message {
+		msg := testMessage(t, fd, name)
+		msg.SetFieldByName("v", value)
+		return msg
+	}
+
+	tests := []struct {
+		name string
+		msg  *dynamic.Message
+		dmh  *DynMsgHelper
+		want string
+	}{
+		{name: "record", msg: testRecord(t, fd),
+			want: "name: a \"b\"\ninner:\n  name: in\n  num: 2\n" +
+				"inners:\n- name: x\n  num: 1\n- name: \"y\"\n" +
+				"tags:\n- a\n- b\ncounts:\n  a: 1\n  b: 2\n" +
+				"status: STATUS_ACTIVE\ndata: aGkA\nratio: 0.5\ncreated: \"2020-01-02T03:04:05Z\"\n"},
+		{name: "field getter", msg: testRecord(t, fd), dmh: NewDynMsgHelper(WithDMHFieldValueGetters(&testInnerGetter{})),
+			want: "name: a \"b\"\ninner: in/2\n" +
+				"inners:\n- name: x\n  num: 1\n- name: \"y\"\n" +
+				"tags:\n- a\n- b\ncounts:\n  a: 1\n  b: 2\n" +
+				"status: STATUS_ACTIVE\ndata: aGkA\nratio: 0.5\ncreated: \"2020-01-02T03:04:05Z\"\n"},
+		{name: "unknown enum number", msg: scalar("E_enum", int32(7)), want: "v: 7\n"},
+		{name: "map int keys", msg: scalar("M_int32_string", map[interface{}]interface{}{int32(10): "x", int32(2): "y"}),
+			want: "v:\n  2: \"y\"\n  10: x\n"},
+		{name: "map enum values", msg: scalar("M_string_enum", map[interface{}]interface{}{"a": int32(2)}),
+			want: "v:\n  a: STATUS_NOT_ACTIVE\n"},
+		{name: "duration", msg: scalar("W_duration", durationpb.New(1500*time.Millisecond)), want: "v: 1.5s\n"},
+		{name: "empty", msg: testMessage(t, fd, "Record"), want: "{}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dmh := tt.dmh
+			if dmh == nil {
+				dmh = NewDynMsgHelper()
+			}
+
+			var b bytes.Buffer
+			err := NewYAMLInvokeOutput(&b).OutputInvoke(dmh, tt.msg)
+			if err != nil {
+				t.Fatalf("Error in output: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Output is %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLInvokeOutputStream(t *testing.T) {
+	fd := testFileDescriptor(t)
+	dmh := NewDynMsgHelper()
+
+	var b bytes.Buffer
+	output := NewYAMLInvokeOutput(&b)
+	err := output.OutputInvokeStreamBegin(dmh, nil)
+	if err == nil {
+		for _, name := range []string{"a", "b"} {
+			err = output.OutputInvokeStreamMessage(dmh, testInner(t, fd, name))
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = output.OutputInvokeStreamEnd(dmh, nil, nil)
+	}
+	if err != nil {
+		t.Fatalf("Error in output: %v", err)
+	}
+
+	// separate documents
+	if want := "name: a\n---\nname: b\n"; b.String() != want {
+		t.Errorf("Output is %q, want %q", b.String(), want)
+	}
+}